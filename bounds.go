@@ -0,0 +1,278 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"errors"
+	"math"
+	"runtime"
+)
+
+// Bounds is a bounding box in the axis order of the projection.
+type Bounds struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// TransformBounds transforms the bounds from Src to Dst. densifyPoints is the
+// number of points added to each edge of the bounds to account for non-linear
+// transformations (21 is a good default).
+//
+// crossesAntimeridian is true if Dst is a geographic projection and the
+// resulting bounds cross the antimeridian. The minimum longitude is greater
+// than the maximum longitude in this case, see TransformBoundsSplit.
+//
+// The bounds are transformed with the same coordinate operation as
+// Transform, including the TransformOptions of the transformer.
+func (t *Transformer) TransformBounds(b Bounds, densifyPoints int) (result Bounds, crossesAntimeridian bool, err error) {
+	result, crossesAntimeridian, _, err = t.transformBounds(b, densifyPoints)
+	return result, crossesAntimeridian, err
+}
+
+// TransformBoundsSplit transforms the bounds from Src to Dst like
+// TransformBounds, but returns two bounds, west and east of the
+// antimeridian, if the resulting bounds cross the antimeridian. Takes the
+// axis order of Dst into account, unlike SplitAntimeridian.
+func (t *Transformer) TransformBoundsSplit(b Bounds, densifyPoints int) ([]Bounds, error) {
+	result, crosses, latFirst, err := t.transformBounds(b, densifyPoints)
+	if err != nil {
+		return nil, err
+	}
+	if !crosses {
+		return []Bounds{result}, nil
+	}
+	if latFirst {
+		return []Bounds{
+			{MinX: result.MinX, MinY: result.MinY, MaxX: result.MaxX, MaxY: 180},
+			{MinX: result.MinX, MinY: -180, MaxX: result.MaxX, MaxY: result.MaxY},
+		}, nil
+	}
+	return result.SplitAntimeridian(), nil
+}
+
+// transformBounds transforms the bounds like TransformBounds. latFirst is
+// true if Dst is a geographic projection with latitude/longitude axis order.
+func (t *Transformer) transformBounds(b Bounds, densifyPoints int) (result Bounds, crossesAntimeridian, latFirst bool, err error) {
+	if t.Src == nil || t.Dst == nil {
+		return Bounds{}, false, false, errors.New("missing/invalid projection")
+	}
+	tr, release, err := acquireTransformation(t.Src, t.Dst, t.opts)
+	if err != nil {
+		return Bounds{}, false, false, err
+	}
+	defer release()
+	defer runtime.KeepAlive(t.Dst)
+	defer runtime.KeepAlive(t.Src)
+
+	var minX, minY, maxX, maxY C.double
+	r := C.proj_trans_bounds(tr.ctx, tr.pj, tr.dir,
+		C.double(b.MinX), C.double(b.MinY), C.double(b.MaxX), C.double(b.MaxY),
+		&minX, &minY, &maxX, &maxY,
+		C.int(densifyPoints),
+	)
+	if r == 0 {
+		return Bounds{}, false, false, ctxError(tr.ctx)
+	}
+	result = Bounds{
+		MinX: float64(minX),
+		MinY: float64(minY),
+		MaxX: float64(maxX),
+		MaxY: float64(maxY),
+	}
+
+	if t.Dst.IsLatLong() {
+		first, _, ok := t.Dst.geographicAxes()
+		if ok && first {
+			latFirst = true
+			crossesAntimeridian = result.MinY > result.MaxY
+		} else if ok {
+			crossesAntimeridian = result.MinX > result.MaxX
+		}
+	}
+	return result, crossesAntimeridian, latFirst, nil
+}
+
+// SplitAntimeridian splits lon/lat bounds (in degree) that cross the
+// antimeridian (MinX > MaxX) into two bounds, west and east of the
+// antimeridian. Returns b as the only element if it does not cross the
+// antimeridian. b needs to be in longitude/latitude order, use
+// TransformBoundsSplit for bounds of projections with latitude/longitude
+// order (e.g. EPSG:4326).
+func (b Bounds) SplitAntimeridian() []Bounds {
+	if b.MinX <= b.MaxX {
+		return []Bounds{b}
+	}
+	return []Bounds{
+		{MinX: b.MinX, MinY: b.MinY, MaxX: 180, MaxY: b.MaxY},
+		{MinX: -180, MinY: b.MinY, MaxX: b.MaxX, MaxY: b.MaxY},
+	}
+}
+
+// TransformClip transforms coordinates from Src to Dst like Transform, and
+// returns all transformed coordinates that are finite and within the area of
+// use of Dst. Coordinates that PROJ fails to transform and invalid input
+// coordinates (e.g. latitudes out of range) are dropped instead of returning
+// a TransformError or an InvalidCoordinateError. Transforms coordinates
+// in-place, kept shares no memory with pts.
+func (t *Transformer) TransformClip(pts []Coord) (kept []Coord, err error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	area, hasArea, err := t.dstAreaBounds()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.transformLenient(pts); err != nil {
+		return nil, err
+	}
+	for _, pt := range pts {
+		if !isFinite(pt) {
+			continue
+		}
+		if hasArea && !(inRange(pt.X, area.MinX, area.MaxX) && inRange(pt.Y, area.MinY, area.MaxY)) {
+			continue
+		}
+		kept = append(kept, pt)
+	}
+	return kept, nil
+}
+
+// TransformGridMasked transforms coordinates from Src to Dst like Transform,
+// e.g. the pixel coordinates of a raster, and returns a mask that is true
+// for all coordinates that were transformed to finite values. Coordinates
+// that PROJ fails to transform and invalid input coordinates (e.g. latitudes
+// out of range, or NaN with TransformOptions.RejectNonFinite) are marked as
+// invalid and set to +Inf, instead of returning a TransformError or an
+// InvalidCoordinateError. Transforms coordinates in-place.
+func (t *Transformer) TransformGridMasked(coords []Coord) (validMask []bool, err error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	if err := t.transformLenient(coords); err != nil {
+		return nil, err
+	}
+	validMask = make([]bool, len(coords))
+	for i, pt := range coords {
+		validMask[i] = isFinite(pt)
+	}
+	return validMask, nil
+}
+
+// transformLenient transforms pts like Transform, but ignores
+// TransformErrors and InvalidCoordinateErrors. Coordinates that PROJ fails
+// to transform and invalid input coordinates (see
+// TransformOptions.RejectNonFinite and ValidateGeographic) are set to +Inf.
+func (t *Transformer) transformLenient(pts []Coord) error {
+	if t.ClampLatitude > 0 {
+		// clamp before the validation, as Transform does
+		t.Src.clampLatitudes(pts, t.ClampLatitude)
+	}
+	invalid, n := t.invalidInputs(pts)
+	if n == 0 {
+		return t.transformChunks(pts)
+	}
+
+	// Only transform the valid coordinates.
+	scratch := getScratch(len(pts) - n)
+	defer putScratch(scratch)
+	valid := (*scratch)[:0]
+	for i, pt := range pts {
+		if !invalid[i] {
+			valid = append(valid, pt)
+		}
+	}
+	if err := t.transformChunks(valid); err != nil {
+		return err
+	}
+	inf := math.Inf(1)
+	for i := range pts {
+		if invalid[i] {
+			pts[i] = Coord{X: inf, Y: inf, Z: inf, T: inf}
+			continue
+		}
+		pts[i], valid = valid[0], valid[1:]
+	}
+	return nil
+}
+
+// invalidInputs marks all coordinates that Transform would reject with an
+// InvalidCoordinateError and returns the number of invalid coordinates.
+// invalid is nil if all coordinates are valid.
+func (t *Transformer) invalidInputs(pts []Coord) (invalid []bool, n int) {
+	var geo *geographicRange
+	if t.opts.ValidateGeographic && t.Src.IsLatLong() {
+		if r, ok := t.Src.geographicRange(t.opts); ok {
+			geo = &r
+		}
+	}
+	for i, pt := range pts {
+		if (t.opts.RejectNonFinite && checkFinite(i, pt) != nil) || (geo != nil && geo.check(i, pt) != nil) {
+			if invalid == nil {
+				invalid = make([]bool, len(pts))
+			}
+			invalid[i] = true
+			n++
+		}
+	}
+	return invalid, n
+}
+
+// transformChunks transforms pts like Transform in chunks of
+// TransformChunkSize, so that a TransformError does not stop the
+// transformation of the following coordinates. TransformErrors are ignored.
+func (t *Transformer) transformChunks(pts []Coord) error {
+	chunkSize := TransformChunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(pts)
+	}
+	for offset := 0; offset < len(pts); offset += chunkSize {
+		chunk := pts[offset:]
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		if err := t.Transform(chunk); err != nil {
+			if _, ok := err.(*TransformError); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isFinite returns whether X and Y of pt are neither NaN nor infinite.
+func isFinite(pt Coord) bool {
+	return !(math.IsNaN(pt.X) || math.IsInf(pt.X, 0) || math.IsNaN(pt.Y) || math.IsInf(pt.Y, 0))
+}
+
+// dstAreaBounds returns the area of use of Dst in the coordinates of Dst.
+// ok is false if the area of use is unknown.
+func (t *Transformer) dstAreaBounds() (b Bounds, ok bool, err error) {
+	area := areaOfUse(t.Dst.context(), t.Dst.p)
+	if area == nil {
+		return Bounds{}, false, nil
+	}
+	geo, err := NewEPSG(4326)
+	if err != nil {
+		return Bounds{}, false, err
+	}
+	defer geo.Free()
+	if err := geo.NormalizeForVisualization(); err != nil {
+		return Bounds{}, false, err
+	}
+	toDst := Transformer{Src: geo, Dst: t.Dst}
+	b, _, err = toDst.TransformBounds(Bounds{MinX: area.West, MinY: area.South, MaxX: area.East, MaxY: area.North}, 21)
+	if err != nil {
+		return Bounds{}, false, err
+	}
+	return b, true, nil
+}
+
+// inRange returns whether v is within [min, max], or outside of (max, min)
+// if min > max (e.g. for longitudes crossing the antimeridian).
+func inRange(v, min, max float64) bool {
+	if min <= max {
+		return v >= min && v <= max
+	}
+	return v >= min || v <= max
+}
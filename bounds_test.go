@@ -0,0 +1,243 @@
+package proj
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransformBounds(t *testing.T) {
+	transf, err := NewEPSGTransformer(25832, 4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	b, crosses, err := transf.TransformBounds(Bounds{400000, 5800000, 500000, 5900000}, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crosses {
+		t.Error("bounds cross antimeridian", b)
+	}
+	if b.MinX < 7 || b.MaxX > 9.1 || b.MinY < 52 || b.MaxY > 53.5 || b.MinX > b.MaxX || b.MinY > b.MaxY {
+		t.Error("unexpected bounds", b)
+	}
+	if split := b.SplitAntimeridian(); len(split) != 1 || split[0] != b {
+		t.Error("unexpected split", split)
+	}
+}
+
+func TestTransformBoundsAntimeridian(t *testing.T) {
+	// NZGD2000 / NZCS2000
+	transf, err := NewEPSGTransformer(3851, 4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	b, crosses, err := transf.TransformBounds(Bounds{1722483.900174921, 5228058.6143420935, 4624385.494808555, 8692574.544944234}, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crosses {
+		t.Fatal("bounds do not cross antimeridian", b)
+	}
+	if b.MinX < 140 || b.MinX > 180 || b.MaxX > -140 || b.MaxX < -180 {
+		t.Error("unexpected bounds", b)
+	}
+
+	split := b.SplitAntimeridian()
+	if len(split) != 2 {
+		t.Fatal("unexpected split", split)
+	}
+	if split[0].MinX != b.MinX || split[0].MaxX != 180 || split[1].MinX != -180 || split[1].MaxX != b.MaxX {
+		t.Error("unexpected split", split)
+	}
+	for _, s := range split {
+		if s.MinY != b.MinY || s.MaxY != b.MaxY {
+			t.Error("unexpected split", split)
+		}
+	}
+}
+
+func TestTransformBoundsSplit(t *testing.T) {
+	// NZGD2000 / NZCS2000 to EPSG:4326 with latitude/longitude order
+	transf, err := NewEPSGTransformer(3851, 4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nz := Bounds{1722483.900174921, 5228058.6143420935, 4624385.494808555, 8692574.544944234}
+	b, crosses, err := transf.TransformBounds(nz, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crosses {
+		t.Fatal("bounds do not cross antimeridian", b)
+	}
+	if b.MinY < 140 || b.MinY > 180 || b.MaxY > -140 || b.MaxY < -180 {
+		t.Error("unexpected bounds", b)
+	}
+
+	split, err := transf.TransformBoundsSplit(nz, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != 2 {
+		t.Fatal("unexpected split", split)
+	}
+	if split[0].MinY != b.MinY || split[0].MaxY != 180 || split[1].MinY != -180 || split[1].MaxY != b.MaxY {
+		t.Error("unexpected split", split)
+	}
+	for _, s := range split {
+		if s.MinX != b.MinX || s.MaxX != b.MaxX {
+			t.Error("unexpected split", split)
+		}
+	}
+
+	// longitude/latitude order
+	if err := transf.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	split, err = transf.TransformBoundsSplit(nz, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != 2 || split[0].MaxX != 180 || split[1].MinX != -180 {
+		t.Error("unexpected split", split)
+	}
+}
+
+func TestTransformBoundsOptions(t *testing.T) {
+	// DHDN to WGS 84 via ETRS89
+	transf, err := NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{PivotCRS: "EPSG:4258"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := transf.TransformBounds(Bounds{5890000, 3440000, 5900000, 3450000}, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.MinX < 53 || b.MaxX > 53.3 || b.MinY < 8 || b.MaxY > 8.3 {
+		t.Error("unexpected bounds", b)
+	}
+
+	// bounds use the operation selection of the transformer
+	transf, err = NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{PivotCRS: "4258"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := transf.TransformBounds(Bounds{5890000, 3440000, 5900000, 3450000}, 21); err == nil {
+		t.Error("no error for invalid PivotCRS")
+	}
+}
+
+func TestTransformClip(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{
+		XY(53.2, 8.15),
+		XY(53.2, 30), // east of UTM zone 32 area of use
+		XY(53.3, 8.2),
+	}
+	kept, err := transf.TransformClip(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 2 {
+		t.Fatal("unexpected result", kept)
+	}
+	if !kept[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(kept[0])
+	}
+	if kept[1] != pts[2] {
+		t.Error(kept[1], pts[2])
+	}
+
+	// invalid input coordinates are dropped
+	transf, err = NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{ValidateGeographic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{
+		XY(95, 8.15), // latitude out of range
+		XY(53.2, 8.15),
+	}
+	kept, err = transf.TransformClip(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 || !kept[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error("unexpected result", kept)
+	}
+
+	kept, err = transf.TransformClip(nil)
+	if err != nil || len(kept) != 0 {
+		t.Error("unexpected result for no coordinates", kept, err)
+	}
+}
+
+func TestTransformGridMasked(t *testing.T) {
+	defer func(size int) { TransformChunkSize = size }(TransformChunkSize)
+	TransformChunkSize = 2
+
+	transf, err := NewEPSGTransformer(25832, 4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords := []Coord{
+		XY(443220.719, 5894856.508),
+		XY(443220.719, 1e10), // fails
+		XY(443220.719, 5894856.508),
+		XY(443220.719, 5894856.508),
+	}
+	mask, err := transf.TransformGridMasked(coords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []bool{true, false, true, true}
+	if len(mask) != len(expected) {
+		t.Fatal("unexpected mask", mask)
+	}
+	for i := range expected {
+		if mask[i] != expected[i] {
+			t.Error("unexpected mask", i, mask)
+		}
+	}
+	// transformation continues after failed chunk
+	if !coords[3].ApproxEqual(XY(53.2, 8.15), 1e-6) {
+		t.Error(coords[3])
+	}
+	// invalid input coordinates of geographic source
+	transf, err = NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{RejectNonFinite: true, ValidateGeographic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords = []Coord{
+		XY(53.2, 8.15),
+		XY(95, 8.15), // latitude out of range
+		XY(53.2, math.NaN()),
+		XY(53.2, 8.15),
+	}
+	mask, err = transf.TransformGridMasked(coords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []bool{true, false, false, true}
+	for i := range expected {
+		if mask[i] != expected[i] {
+			t.Error("unexpected mask", i, mask)
+		}
+	}
+	if !math.IsInf(coords[1].X, 1) || !math.IsInf(coords[2].Y, 1) {
+		t.Error("invalid coordinates not set to +Inf", coords)
+	}
+	for _, i := range []int{0, 3} {
+		if !coords[i].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+			t.Error(i, coords[i])
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// TransformCacheSize is the maximum number of transformations that are
+// cached by Transform. Creating the transformation between two projections
+// is expensive, and Transform reuses cached transformations for the same
+// pair of projections and options. Caching is disabled if TransformCacheSize
+// is <= 0.
+var TransformCacheSize = 32
+
+// ClearTransformCache removes all cached transformations.
+func ClearTransformCache() {
+	transformCache.clear()
+}
+
+var transformCache = &lruCache{
+	entries: list.New(),
+	index:   make(map[string]*list.Element),
+}
+
+// transformation is a PJ with its own context. The PJ is not safe for
+// concurrent use.
+type transformation struct {
+	ctx *C.PJ_CONTEXT
+	pj  *C.PJ
+	dir C.PJ_DIRECTION // direction in which pj transforms from src to dst
+}
+
+func (t *transformation) free() {
+	C.proj_destroy(t.pj)
+	destroyContext(t.ctx)
+}
+
+type cacheEntry struct {
+	key string
+	tr  *transformation
+}
+
+// lruCache caches idle transformations. Transformations are removed from the
+// cache while they are in use, so that each transformation is only used by
+// a single goroutine.
+type lruCache struct {
+	mu      sync.Mutex
+	entries *list.List // most recently used first
+	index   map[string]*list.Element
+}
+
+// get removes and returns the cached transformation for key, or nil.
+func (c *lruCache) get(key string) *transformation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.index[key]
+	if !ok {
+		return nil
+	}
+	c.entries.Remove(elem)
+	delete(c.index, key)
+	return elem.Value.(*cacheEntry).tr
+}
+
+// put adds the transformation to the cache and evicts the least recently
+// used transformations. Frees tr if there is already a cached transformation
+// for key.
+func (c *lruCache) put(key string, tr *transformation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[key]; ok || TransformCacheSize <= 0 {
+		tr.free()
+		return
+	}
+	c.index[key] = c.entries.PushFront(&cacheEntry{key: key, tr: tr})
+	for c.entries.Len() > TransformCacheSize {
+		elem := c.entries.Back()
+		c.entries.Remove(elem)
+		entry := elem.Value.(*cacheEntry)
+		delete(c.index, entry.key)
+		entry.tr.free()
+	}
+}
+
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+func (c *lruCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for elem := c.entries.Front(); elem != nil; elem = elem.Next() {
+		elem.Value.(*cacheEntry).tr.free()
+	}
+	c.entries.Init()
+	c.index = make(map[string]*list.Element)
+}
+
+// acquireTransformation returns the transformation from src to dst and a
+// function to release it after use. Returns a cached transformation if
+// available.
+func acquireTransformation(src, dst *Proj, opts TransformOptions) (*transformation, func(), error) {
+	key := ""
+	if TransformCacheSize > 0 {
+		srcKey, dstKey := src.cacheKey(), dst.cacheKey()
+		if srcKey != "" && dstKey != "" {
+			var area [4]float64
+			if opts.AreaOfInterest != nil {
+				area = opts.AreaOfInterest.bbox()
+			}
+			key = fmt.Sprintf("%s\x00%s\x00%q\x00%q\x00%q\x00%q\x00%v\x00%v\x00%v\x00%v",
+				srcKey, dstKey, opts.crsToCRSOptions(), opts.PivotCRS, opts.Method, opts.pipeline,
+				opts.invertPipeline, opts.AreaOfInterest != nil, area, opts.UsePrimaryGridNamesOnly)
+		}
+	}
+	if key == "" {
+		// Not cachable, use context of the projection.
+		pj, err := createTransformation(src.context(), src, dst, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		tr := &transformation{ctx: src.context(), pj: pj, dir: opts.direction()}
+		return tr, func() { C.proj_destroy(pj) }, nil
+	}
+
+	if tr := transformCache.get(key); tr != nil {
+		return tr, func() { transformCache.put(key, tr) }, nil
+	}
+
+	ctx := newContext()
+	pj, err := createTransformation(ctx, src, dst, opts)
+	if err != nil {
+		destroyContext(ctx)
+		return nil, nil, err
+	}
+	tr := &transformation{ctx: ctx, pj: pj, dir: opts.direction()}
+	return tr, func() { transformCache.put(key, tr) }, nil
+}
+
+// cacheKey returns a key that identifies the CRS of the projection, or an
+// empty string if the CRS can not be identified.
+func (p *Proj) cacheKey() string {
+	if p.key == nil {
+		key := ""
+		if wkt := C.proj_as_wkt(p.context(), p.p, C.PJ_WKT2_2019, nil); wkt != nil {
+			key = C.GoString(wkt)
+		}
+		p.key = &key
+	}
+	return *p.key
+}
@@ -0,0 +1,129 @@
+package proj
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTransformCache(t *testing.T) {
+	defer func(size int) { TransformCacheSize = size }(TransformCacheSize)
+	TransformCacheSize = 2
+	ClearTransformCache()
+	defer ClearTransformCache()
+
+	p1, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p3, err := New("epsg:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transform := func(src, dst *Proj, pt, expected Coord) {
+		pts := []Coord{pt}
+		if err := src.Transform(dst, pts); err != nil {
+			t.Fatal(err)
+		}
+		if !pts[0].ApproxEqual(expected, 0.01) {
+			t.Error(src, dst, pts)
+		}
+	}
+
+	transform(p1, p2, XY(53.2, 8.15), XY(443220.719, 5894856.508))
+	if n := transformCache.len(); n != 1 {
+		t.Error("unexpected cache size", n)
+	}
+	transform(p1, p2, XY(53.2, 8.15), XY(443220.719, 5894856.508))
+	if n := transformCache.len(); n != 1 {
+		t.Error("unexpected cache size", n)
+	}
+	transform(p2, p1, XY(443220.719, 5894856.508), XY(53.2, 8.15))
+	transform(p1, p3, XY(53.2, 8.15), XY(907253.85, 7020078.53))
+	if n := transformCache.len(); n != 2 {
+		t.Error("unexpected cache size", n)
+	}
+
+	// normalized projection uses different cache entry
+	if err := p1.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	transform(p1, p2, XY(8.15, 53.2), XY(443220.719, 5894856.508))
+
+	// cached transformations are independent of the projections
+	p1.Free()
+	p2.Free()
+	p1, err = New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform(p1, p3, XY(53.2, 8.15), XY(907253.85, 7020078.53))
+
+	ClearTransformCache()
+	if n := transformCache.len(); n != 0 {
+		t.Error("unexpected cache size", n)
+	}
+}
+
+func TestTransformCacheConcurrent(t *testing.T) {
+	ClearTransformCache()
+	defer ClearTransformCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p1, err := New("epsg:4326")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			p2, err := New("epsg:25832")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for j := 0; j < 50; j++ {
+				pts := []Coord{XY(53.2, 8.15)}
+				if err := p1.Transform(p2, pts); err != nil {
+					t.Error(err)
+					return
+				}
+				if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+					t.Error(pts)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := transformCache.len(); n != 1 {
+		t.Error("unexpected cache size", n)
+	}
+}
+
+func TestTransformCacheDisabled(t *testing.T) {
+	defer func(size int) { TransformCacheSize = size }(TransformCacheSize)
+	TransformCacheSize = 0
+	ClearTransformCache()
+
+	p1, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := p1.Transform(p2, pts); err != nil {
+		t.Fatal(err)
+	}
+	if n := transformCache.len(); n != 0 {
+		t.Error("unexpected cache size", n)
+	}
+}
@@ -0,0 +1,81 @@
+package proj
+
+// Wrappers for functions of newer PROJ versions, so that the package still
+// builds with older versions. The wrappers return NULL/NaN if the function
+// is not available.
+
+// #include <math.h>
+// #include <proj.h>
+//
+// #define PROJ_AT_LEAST(major, minor) \
+//     (PROJ_VERSION_MAJOR > (major) || (PROJ_VERSION_MAJOR == (major) && PROJ_VERSION_MINOR >= (minor)))
+//
+// #if PROJ_AT_LEAST(9, 1)
+// #define HAS_LAST_USED_OPERATION 1
+// static PJ *compat_trans_get_last_used_operation(PJ *P) {
+//     return proj_trans_get_last_used_operation(P);
+// }
+// #else
+// #define HAS_LAST_USED_OPERATION 0
+// static PJ *compat_trans_get_last_used_operation(PJ *P) {
+//     return NULL;
+// }
+// #endif
+//
+// #if PROJ_AT_LEAST(9, 2)
+// #define HAS_COORDINATE_METADATA 1
+// static PJ *compat_coordinate_metadata_create(PJ_CONTEXT *ctx, const PJ *crs, double epoch) {
+//     return proj_coordinate_metadata_create(ctx, crs, epoch);
+// }
+// static double compat_coordinate_metadata_get_epoch(PJ_CONTEXT *ctx, const PJ *obj) {
+//     return proj_coordinate_metadata_get_epoch(ctx, obj);
+// }
+// #else
+// #define HAS_COORDINATE_METADATA 0
+// static PJ *compat_coordinate_metadata_create(PJ_CONTEXT *ctx, const PJ *crs, double epoch) {
+//     return NULL;
+// }
+// static double compat_coordinate_metadata_get_epoch(PJ_CONTEXT *ctx, const PJ *obj) {
+//     return NAN;
+// }
+// #endif
+import "C"
+
+import "fmt"
+
+// hasLastUsedOperation returns whether PROJ supports
+// proj_trans_get_last_used_operation (PROJ 9.1).
+func hasLastUsedOperation() bool {
+	return C.HAS_LAST_USED_OPERATION != 0
+}
+
+// lastUsedOperation returns the operation of pj that was used for the last
+// transformed coordinate, or nil if it is unknown or not supported.
+func lastUsedOperation(pj *C.PJ) *C.PJ {
+	return C.compat_trans_get_last_used_operation(pj)
+}
+
+// hasCoordinateMetadata returns whether PROJ supports coordinate metadata
+// (PROJ 9.2).
+func hasCoordinateMetadata() bool {
+	return C.HAS_COORDINATE_METADATA != 0
+}
+
+// coordinateMetadataCreate returns coordinate metadata of crs with the
+// coordinate epoch, or nil on error.
+func coordinateMetadataCreate(ctx *C.PJ_CONTEXT, crs *C.PJ, epoch float64) *C.PJ {
+	return C.compat_coordinate_metadata_create(ctx, crs, C.double(epoch))
+}
+
+// coordinateMetadataEpoch returns the coordinate epoch of obj, or NaN if obj
+// has no coordinate metadata.
+func coordinateMetadataEpoch(ctx *C.PJ_CONTEXT, obj *C.PJ) float64 {
+	return float64(C.compat_coordinate_metadata_get_epoch(ctx, obj))
+}
+
+// unsupportedError returns the error for functions that require a newer PROJ
+// version.
+func unsupportedError(feature, version string) error {
+	return fmt.Errorf("%s requires PROJ %s or newer, got %d.%d.%d", feature, version,
+		C.PROJ_VERSION_MAJOR, C.PROJ_VERSION_MINOR, C.PROJ_VERSION_PATCH)
+}
@@ -0,0 +1,13 @@
+package proj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnsupportedError(t *testing.T) {
+	err := unsupportedError("Foo", "99.0")
+	if !strings.HasPrefix(err.Error(), "Foo requires PROJ 99.0 or newer, got ") {
+		t.Error("unexpected error", err)
+	}
+}
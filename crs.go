@@ -0,0 +1,246 @@
+package proj
+
+// #include <proj.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// GeographicCRS defines a custom geographic CRS, see NewGeographicCRS.
+type GeographicCRS struct {
+	// Name of the CRS.
+	Name string
+	// DatumName is the name of the geodetic datum.
+	DatumName string
+	// EllipsoidName is the name of the ellipsoid.
+	EllipsoidName string
+	// SemiMajor is the semi-major axis of the ellipsoid in metre.
+	SemiMajor float64
+	// InvFlattening is the inverse flattening of the ellipsoid, or 0 for
+	// a sphere.
+	InvFlattening float64
+	// PrimeMeridianName is the name of the prime meridian. Defaults to
+	// "Greenwich".
+	PrimeMeridianName string
+	// PrimeMeridianOffset is the longitude of the prime meridian relative
+	// to Greenwich in degree.
+	PrimeMeridianOffset float64
+}
+
+// NewGeographicCRS initializes a new projection for a custom geographic CRS.
+// The CRS uses lat/lon axis order in degree, like geographic CRS from the
+// EPSG database. Call NormalizeForVisualization for lon/lat order.
+func NewGeographicCRS(def GeographicCRS) (*Proj, error) {
+	if def.SemiMajor <= 0 {
+		return nil, errors.New("semi-major axis needs to be positive")
+	}
+	if def.InvFlattening < 0 {
+		return nil, errors.New("inverse flattening needs to be positive or 0")
+	}
+	if def.PrimeMeridianName == "" {
+		def.PrimeMeridianName = "Greenwich"
+	}
+
+	name := C.CString(def.Name)
+	defer C.free(unsafe.Pointer(name))
+	datumName := C.CString(def.DatumName)
+	defer C.free(unsafe.Pointer(datumName))
+	ellpsName := C.CString(def.EllipsoidName)
+	defer C.free(unsafe.Pointer(ellpsName))
+	pmName := C.CString(def.PrimeMeridianName)
+	defer C.free(unsafe.Pointer(pmName))
+
+	ctx := newContext()
+	cs := C.proj_create_ellipsoidal_2D_cs(ctx, C.PJ_ELLPS2D_LATITUDE_LONGITUDE, nil, 0)
+	if cs == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	defer C.proj_destroy(cs)
+
+	crs := C.proj_create_geographic_crs(ctx, name, datumName, ellpsName,
+		C.double(def.SemiMajor), C.double(def.InvFlattening),
+		pmName, C.double(def.PrimeMeridianOffset),
+		nil, 0, // degree
+		cs,
+	)
+	if crs == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	return newProj(ctx, crs), nil
+}
+
+// UTMZoneEPSG returns the EPSG code of the WGS 84 / UTM zone for the lon/lat
+// coordinate (in degree), e.g. 32632 for zone 32N or 32732 for zone 32S.
+// Respects the exceptions for southern Norway and Svalbard. Returns 0 for
+// invalid coordinates.
+func UTMZoneEPSG(lon, lat float64) int {
+	if math.IsNaN(lon) || math.IsInf(lon, 0) || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return 0
+	}
+	lon = wrapLongitude(lon, 180)
+	zone := int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+
+	switch {
+	case lat >= 56 && lat < 64 && lon >= 3 && lon < 12:
+		// southern Norway
+		zone = 32
+	case lat >= 72 && lat < 84 && lon >= 0 && lon < 42:
+		// Svalbard
+		switch {
+		case lon < 9:
+			zone = 31
+		case lon < 21:
+			zone = 33
+		case lon < 33:
+			zone = 35
+		default:
+			zone = 37
+		}
+	}
+
+	if lat < 0 {
+		return 32700 + zone
+	}
+	return 32600 + zone
+}
+
+// NewRotatedPole initializes a new projection for a rotated pole grid (as
+// used by many weather and climate models) with the geographic position of
+// the rotated north pole in degree (e.g. grid_north_pole_longitude and
+// grid_north_pole_latitude of CF conventions).
+// Rotated coordinates are in lon/lat order and in degree.
+func NewRotatedPole(poleLon, poleLat float64) (*Proj, error) {
+	if poleLat < -90 || poleLat > 90 {
+		return nil, fmt.Errorf("invalid pole latitude %v", poleLat)
+	}
+	lon0 := wrapLongitude(poleLon+180, 180)
+	return New(fmt.Sprintf(
+		"+proj=ob_tran +o_proj=longlat +o_lon_p=0 +o_lat_p=%v +lon_0=%v +datum=WGS84 +no_defs +type=crs",
+		poleLat, lon0,
+	))
+}
+
+// NewLocalTM initializes a new transverse mercator projection on the WGS 84
+// ellipsoid, centered at the geographic position (in degree), e.g. for
+// local engineering coordinate systems with minimal distortion around the
+// center. The center is at X=0/Y=0 with a scale factor of 1. Coordinates are
+// in east/north order and in metre.
+func NewLocalTM(centerLon, centerLat float64) (*Proj, error) {
+	if centerLat < -90 || centerLat > 90 {
+		return nil, fmt.Errorf("invalid center latitude %v", centerLat)
+	}
+	if centerLon < -180 || centerLon > 180 {
+		return nil, fmt.Errorf("invalid center longitude %v", centerLon)
+	}
+	return New(fmt.Sprintf(
+		"+proj=tmerc +lat_0=%v +lon_0=%v +k=1 +x_0=0 +y_0=0 +datum=WGS84 +units=m +no_defs +type=crs",
+		centerLat, centerLon,
+	))
+}
+
+// UTMTransformers returns transformers from the srcEPSG projection to all 60
+// WGS 84 / UTM zones, either north (EPSG:32601-32660) or south
+// (EPSG:32701-32760). The map key is the zone number. All transformers
+// share the same Src projection and must not be used concurrently.
+func UTMTransformers(srcEPSG int, north bool) (map[int]Transformer, error) {
+	src, err := NewEPSG(srcEPSG)
+	if err != nil {
+		return nil, err
+	}
+	base := 32700
+	if north {
+		base = 32600
+	}
+	transformers := make(map[int]Transformer, 60)
+	for zone := 1; zone <= 60; zone++ {
+		dst, err := NewEPSG(base + zone)
+		if err != nil {
+			return nil, err
+		}
+		transformers[zone] = Transformer{Src: src, Dst: dst}
+	}
+	return transformers, nil
+}
+
+// ConversionMethod returns the name of the projection method of a projected
+// CRS (e.g. "Transverse Mercator" or "Lambert Conic Conformal (2SP)").
+// Returns an error if p is not a projected CRS.
+func (p *Proj) ConversionMethod() (name string, err error) {
+	ctx := p.context()
+	conv, err := p.conversion(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer C.proj_destroy(conv)
+
+	var cName *C.char
+	if C.proj_coordoperation_get_method_info(ctx, conv, &cName, nil, nil) == 0 || cName == nil {
+		return "", ctxError(ctx)
+	}
+	return C.GoString(cName), nil
+}
+
+// conversion returns the conversion of a projected CRS. The returned
+// conversion needs to be destroyed by the caller.
+func (p *Proj) conversion(ctx *C.PJ_CONTEXT) (*C.PJ, error) {
+	if C.proj_get_type(p.p) != C.PJ_TYPE_PROJECTED_CRS {
+		return nil, errors.New("projection is not a projected CRS")
+	}
+	conv := C.proj_crs_get_coordoperation(ctx, p.p)
+	if conv == nil {
+		return nil, ctxError(ctx)
+	}
+	return conv, nil
+}
+
+// Parameter is a parameter of the conversion of a projected CRS.
+type Parameter struct {
+	// Name of the parameter, e.g. "Longitude of natural origin".
+	Name string
+	// Value of the parameter in Unit.
+	Value float64
+	// Unit of the value, e.g. "degree", "metre" or "unity".
+	Unit string
+}
+
+// ConversionParameters returns the parameters of the conversion of a
+// projected CRS (e.g. the central meridian and the scale factor of a
+// Transverse Mercator projection), see ConversionMethod. Returns an error if
+// p is not a projected CRS.
+func (p *Proj) ConversionParameters() ([]Parameter, error) {
+	ctx := p.context()
+	conv, err := p.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer C.proj_destroy(conv)
+
+	n := int(C.proj_coordoperation_get_param_count(ctx, conv))
+	params := make([]Parameter, 0, n)
+	for i := 0; i < n; i++ {
+		var name, unit *C.char
+		var value C.double
+		if C.proj_coordoperation_get_param(ctx, conv, C.int(i),
+			&name, nil, nil, &value, nil, nil, &unit, nil, nil, nil) == 0 {
+			return nil, ctxError(ctx)
+		}
+		params = append(params, Parameter{
+			Name:  C.GoString(name),
+			Value: float64(value),
+			Unit:  C.GoString(unit),
+		})
+	}
+	return params, nil
+}
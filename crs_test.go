@@ -0,0 +1,254 @@
+package proj
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewGeographicCRS(t *testing.T) {
+	p, err := NewGeographicCRS(GeographicCRS{
+		Name:          "Custom WGS 84",
+		DatumName:     "Custom datum",
+		EllipsoidName: "WGS 84",
+		SemiMajor:     6378137,
+		InvFlattening: 298.257223563,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsLatLong() {
+		t.Error("not LatLong")
+	}
+	if name, _ := p.Name(); name != "Custom WGS 84" {
+		t.Error("unexpected name", name)
+	}
+	if u := p.UnitName(); u != "degree" {
+		t.Error("unexpected unit", u)
+	}
+
+	// lat/lon order
+	utm, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := p.Transform(utm, pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
+	}
+
+	if _, err := NewGeographicCRS(GeographicCRS{Name: "invalid"}); err == nil {
+		t.Error("no error for missing semi-major axis")
+	}
+	if _, err := NewGeographicCRS(GeographicCRS{SemiMajor: 6378137, InvFlattening: -1}); err == nil {
+		t.Error("no error for negative inverse flattening")
+	}
+}
+
+func TestUTMZoneEPSG(t *testing.T) {
+	var tests = []struct {
+		lon, lat float64
+		epsg     int
+	}{
+		{8.15, 53.2, 32632},
+		{9, 53.2, 32632},
+		{8.15, -53.2, 32732},
+		{-180, 0, 32601},
+		{180, 0, 32660},
+		{179.9, -10, 32760},
+		{-74, 40.7, 32618},
+		{185, 10, 32601},
+		{5, 60, 32632},  // Norway
+		{2, 60, 32631},  // not Norway
+		{8, 78, 32631},  // Svalbard
+		{15, 78, 32633}, // Svalbard
+		{25, 78, 32635}, // Svalbard
+		{40, 78, 32637}, // Svalbard
+		{8.15, 91, 0},
+		{math.NaN(), 53.2, 0},
+	}
+	for _, tt := range tests {
+		if epsg := UTMZoneEPSG(tt.lon, tt.lat); epsg != tt.epsg {
+			t.Errorf("%d != %d for %v/%v", epsg, tt.epsg, tt.lon, tt.lat)
+		}
+	}
+}
+
+func TestNewRotatedPole(t *testing.T) {
+	// EURO-CORDEX
+	p, err := NewRotatedPole(-162, 39.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wgs84, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wgs84.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+
+	// origin of the rotated grid is at the meridian opposite of the pole
+	pts := []Coord{XY(0, 0), XY(0, 39.25)}
+	if err := p.Transform(wgs84, pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(18, 50.75), 1e-6) {
+		t.Error(pts[0])
+	}
+	// rotated latitude of 90-poleLat is the geographic north pole
+	if !approxEqual(pts[1].Y, 90, 1e-6) {
+		t.Error(pts[1])
+	}
+
+	if err := wgs84.Transform(p, pts[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(0, 0), 1e-6) {
+		t.Error(pts[0])
+	}
+
+	if _, err := NewRotatedPole(0, 91); err == nil {
+		t.Error("no error for invalid pole")
+	}
+}
+
+func TestNewLocalTM(t *testing.T) {
+	p, err := NewLocalTM(8.15, 53.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	if method, err := p.ConversionMethod(); err != nil || method != "Transverse Mercator" {
+		t.Error("unexpected method", method, err)
+	}
+
+	wgs84, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Free()
+	if err := wgs84.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(8.15, 53.2), XY(8.15, 53.21)}
+	if err := wgs84.Transform(p, pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(0, 0), 1e-6) {
+		t.Error(pts[0])
+	}
+	// 0.01° north of the center, along the central meridian
+	if !approxEqual(pts[1].X, 0, 1e-6) || !approxEqual(pts[1].Y, 1113, 5) {
+		t.Error(pts[1])
+	}
+
+	if _, err := NewLocalTM(8.15, 91); err == nil {
+		t.Error("no error for invalid center")
+	}
+}
+
+func TestUTMTransformers(t *testing.T) {
+	transformers, err := UTMTransformers(4326, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transformers) != 60 {
+		t.Fatal("unexpected number of transformers", len(transformers))
+	}
+	transf := transformers[32]
+	if transf.Src != transformers[1].Src {
+		t.Error("source projection not shared")
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
+	}
+
+	transformers, err = UTMTransformers(4326, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := transformers[60].Dst.Name(); name != "WGS 84 / UTM zone 60S" {
+		t.Error("unexpected projection", name)
+	}
+}
+
+func TestConversionMethod(t *testing.T) {
+	var tests = []struct {
+		epsg   int
+		method string
+	}{
+		{25832, "Transverse Mercator"},
+		{3857, "Popular Visualisation Pseudo Mercator"},
+		{3035, "Lambert Azimuthal Equal Area"},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		method, err := p.ConversionMethod()
+		if err != nil {
+			t.Error(tt.epsg, err)
+		} else if method != tt.method {
+			t.Errorf("unexpected method for %d: %q", tt.epsg, method)
+		}
+		p.Free()
+	}
+
+	p, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	if _, err := p.ConversionMethod(); err == nil {
+		t.Error("no error for geographic CRS")
+	}
+}
+
+func TestConversionParameters(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	params, err := p.ConversionParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Parameter{
+		"Longitude of natural origin":    {Value: 9, Unit: "degree"},
+		"Scale factor at natural origin": {Value: 0.9996, Unit: "unity"},
+		"False easting":                  {Value: 500000, Unit: "metre"},
+		"False northing":                 {Value: 0, Unit: "metre"},
+		"Latitude of natural origin":     {Value: 0, Unit: "degree"},
+	}
+	if len(params) != len(expected) {
+		t.Fatal("unexpected parameters", params)
+	}
+	for _, param := range params {
+		e, ok := expected[param.Name]
+		if !ok {
+			t.Error("unexpected parameter", param)
+			continue
+		}
+		if param.Value != e.Value || param.Unit != e.Unit {
+			t.Error("unexpected value", param)
+		}
+	}
+
+	geo, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer geo.Free()
+	if _, err := geo.ConversionParameters(); err == nil {
+		t.Error("no error for geographic CRS")
+	}
+}
@@ -0,0 +1,323 @@
+package proj
+
+// #include <proj.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// CheckInstallation checks that the PROJ database (proj.db) can be found.
+// Call CheckInstallation at startup to fail early with a clear error if the
+// PROJ data directory is misconfigured, instead of failing for each
+// projection.
+func CheckInstallation() error {
+	_, err := DatabasePath()
+	return err
+}
+
+// SelfTest checks the installation (see CheckInstallation) and transforms a
+// few coordinates with known results, to verify that PROJ and its data work
+// correctly. Call SelfTest at startup to fail early.
+func SelfTest() error {
+	if err := CheckInstallation(); err != nil {
+		return err
+	}
+	tests := []struct {
+		src, dst int
+		in, out  Coord
+		tol      float64
+	}{
+		{4326, 25832, XY(53.2, 8.15), XY(443220.719, 5894856.508), 0.01},
+		{4326, 3857, XY(53.2, 8.15), XY(907253.85, 7020078.53), 0.01},
+		{25832, 4326, XY(443220.719, 5894856.508), XY(53.2, 8.15), 1e-6},
+	}
+	for _, tt := range tests {
+		t, err := NewEPSGTransformer(tt.src, tt.dst)
+		if err != nil {
+			return fmt.Errorf("self test EPSG:%d to EPSG:%d: %w", tt.src, tt.dst, err)
+		}
+		pts := []Coord{tt.in}
+		err = t.Transform(pts)
+		t.Src.Free()
+		t.Dst.Free()
+		if err != nil {
+			return fmt.Errorf("self test EPSG:%d to EPSG:%d: %w", tt.src, tt.dst, err)
+		}
+		if !pts[0].ApproxEqual(tt.out, tt.tol) {
+			return fmt.Errorf("self test EPSG:%d to EPSG:%d: expected %v, got %v", tt.src, tt.dst, tt.out, pts[0])
+		}
+	}
+	return nil
+}
+
+// DatabasePath returns the path of the PROJ database (proj.db) that is used
+// by all projections.
+func DatabasePath() (string, error) {
+	ctx := newContext()
+	defer destroyContext(ctx)
+
+	path := C.proj_context_get_database_path(ctx)
+	if path == nil {
+		info := C.proj_info()
+		return "", fmt.Errorf("PROJ data directory not found; set PROJ_DATA (search path: %s)", C.GoString(info.searchpath))
+	}
+	return C.GoString(path), nil
+}
+
+// NewEPSGBatch initializes new projections for all EPSG codes (see NewEPSG).
+// Like New, all projections are created within the shared lookup context,
+// which is only locked once for all codes. Returns the projections of all
+// valid codes, and an *EPSGBatchError if some codes are invalid.
+func NewEPSGBatch(codes []int) (map[int]*Proj, error) {
+	lookup.Lock()
+	defer lookup.Unlock()
+	ctx := lookupContext()
+	if ctx == nil {
+		return nil, errors.New("unable to create PROJ context")
+	}
+
+	projs := make(map[int]*Proj, len(codes))
+	failed := make(map[int]error)
+	for _, code := range codes {
+		if _, ok := projs[code]; ok {
+			continue
+		}
+		if _, ok := failed[code]; ok {
+			continue
+		}
+		c := C.CString(fmt.Sprintf("epsg:%d", code))
+		pj := C.proj_create(ctx, c)
+		C.free(unsafe.Pointer(c))
+		if pj == nil {
+			failed[code] = ctxError(ctx)
+			continue
+		}
+		projs[code] = newProj(nil, pj)
+	}
+	if len(failed) > 0 {
+		return projs, &EPSGBatchError{Errors: failed}
+	}
+	return projs, nil
+}
+
+// EPSGBatchError is returned by NewEPSGBatch if some codes are invalid.
+type EPSGBatchError struct {
+	// Errors for each invalid code.
+	Errors map[int]error
+}
+
+func (e *EPSGBatchError) Error() string {
+	codes := make([]int, 0, len(e.Errors))
+	for code := range e.Errors {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	msgs := make([]string, 0, len(codes))
+	for _, code := range codes {
+		msgs = append(msgs, fmt.Sprintf("EPSG:%d: %s", code, e.Errors[code]))
+	}
+	return fmt.Sprintf("unable to create %d projections: %s", len(codes), strings.Join(msgs, "; "))
+}
+
+// EPSGDatabaseVersion returns the version of the EPSG dataset in the PROJ
+// database (e.g. "v10.076").
+func EPSGDatabaseVersion() (string, error) {
+	ctx := newContext()
+	defer destroyContext(ctx)
+
+	key := C.CString("EPSG.VERSION")
+	defer C.free(unsafe.Pointer(key))
+	version := C.proj_context_get_database_metadata(ctx, key)
+	if version == nil {
+		if C.proj_context_errno(ctx) != 0 {
+			return "", ctxError(ctx)
+		}
+		return "", errors.New("EPSG version not found in PROJ database")
+	}
+	return C.GoString(version), nil
+}
+
+// UnitCategory is the category of a unit of measure.
+type UnitCategory string
+
+const (
+	AllUnits           UnitCategory = ""
+	UnitLinear         UnitCategory = "linear"
+	UnitLinearPerTime  UnitCategory = "linear_per_time"
+	UnitAngular        UnitCategory = "angular"
+	UnitAngularPerTime UnitCategory = "angular_per_time"
+	UnitScale          UnitCategory = "scale"
+	UnitScalePerTime   UnitCategory = "scale_per_time"
+	UnitTime           UnitCategory = "time"
+	UnitParametric     UnitCategory = "parametric"
+)
+
+// UnitInfo describes a unit of measure from the PROJ database.
+type UnitInfo struct {
+	AuthName string
+	Code     string
+	Name     string
+	Category UnitCategory
+	// ConvFactor converts a value in this unit into the SI unit of the
+	// category (e.g. metre for linear units, radian for angular units).
+	ConvFactor float64
+	// ProjShortName is the short name used in proj strings (e.g. "us-ft"),
+	// or empty if there is none.
+	ProjShortName string
+	Deprecated    bool
+}
+
+// ListUnits returns all non-deprecated units of measure of the category from
+// the PROJ database. Returns units of all categories for AllUnits.
+func ListUnits(category UnitCategory) ([]UnitInfo, error) {
+	ctx := newContext()
+	defer destroyContext(ctx)
+
+	var cCategory *C.char
+	if category != AllUnits {
+		cCategory = C.CString(string(category))
+		defer C.free(unsafe.Pointer(cCategory))
+	}
+
+	var count C.int
+	list := C.proj_get_units_from_database(ctx, nil, cCategory, 0, &count)
+	if list == nil {
+		return nil, ctxError(ctx)
+	}
+	defer C.proj_unit_list_destroy(list)
+
+	units := make([]UnitInfo, 0, int(count))
+	for _, u := range (*[1 << 28]*C.PROJ_UNIT_INFO)(unsafe.Pointer(list))[:count:count] {
+		units = append(units, UnitInfo{
+			AuthName:      C.GoString(u.auth_name),
+			Code:          C.GoString(u.code),
+			Name:          C.GoString(u.name),
+			Category:      UnitCategory(C.GoString(u.category)),
+			ConvFactor:    float64(u.conv_factor),
+			ProjShortName: C.GoString(u.proj_short_name),
+			Deprecated:    u.deprecated != 0,
+		})
+	}
+	return units, nil
+}
+
+// ValidateEPSG checks whether all codes can be resolved as EPSG codes (see
+// NewEPSG). Returns the error for each code that can not be resolved. The
+// map is empty if all codes are valid. All codes are resolved with a single
+// PROJ context, which is faster than calling NewEPSG for each code.
+func ValidateEPSG(codes ...int) (map[int]error, error) {
+	ctx := newContext()
+	if ctx == nil {
+		return nil, errors.New("unable to create PROJ context")
+	}
+	defer destroyContext(ctx)
+
+	failed := make(map[int]error)
+	for _, code := range codes {
+		if _, ok := failed[code]; ok {
+			continue
+		}
+		c := C.CString(fmt.Sprintf("epsg:%d", code))
+		pj := C.proj_create(ctx, c)
+		C.free(unsafe.Pointer(c))
+		if pj == nil {
+			failed[code] = ctxError(ctx)
+			continue
+		}
+		C.proj_destroy(pj)
+	}
+	return failed, nil
+}
+
+// CRSInfo describes a CRS from the PROJ database.
+type CRSInfo struct {
+	AuthName string
+	Code     string
+	Name     string
+	// ProjectionMethod is the name of the projection method (e.g.
+	// "Transverse Mercator") of projected CRS.
+	ProjectionMethod string
+	// AreaOfUse of the CRS, or nil if unknown.
+	AreaOfUse  *AreaOfUse
+	Deprecated bool
+}
+
+// SuggestCRS returns all non-deprecated projected EPSG CRS with an area of
+// use that contains the extent (in degree). The CRS are ordered by their
+// fit, CRS with the smallest area of use first.
+func SuggestCRS(west, south, east, north float64) ([]CRSInfo, error) {
+	ctx := newContext()
+	defer destroyContext(ctx)
+
+	params := C.proj_get_crs_list_parameters_create()
+	defer C.proj_get_crs_list_parameters_destroy(params)
+	types := (*C.PJ_TYPE)(C.malloc(C.size_t(unsafe.Sizeof(C.PJ_TYPE(0)))))
+	defer C.free(unsafe.Pointer(types))
+	*types = C.PJ_TYPE_PROJECTED_CRS
+	params.types = types
+	params.typesCount = 1
+	params.crs_area_of_use_contains_bbox = 1
+	params.bbox_valid = 1
+	params.west_lon_degree = C.double(west)
+	params.south_lat_degree = C.double(south)
+	params.east_lon_degree = C.double(east)
+	params.north_lat_degree = C.double(north)
+	params.allow_deprecated = 0
+
+	auth := C.CString("EPSG")
+	defer C.free(unsafe.Pointer(auth))
+
+	var count C.int
+	list := C.proj_get_crs_info_list_from_database(ctx, auth, params, &count)
+	if list == nil {
+		return nil, ctxError(ctx)
+	}
+	defer C.proj_crs_info_list_destroy(list)
+
+	crs := make([]CRSInfo, 0, int(count))
+	for _, c := range (*[1 << 28]*C.PROJ_CRS_INFO)(unsafe.Pointer(list))[:count:count] {
+		info := CRSInfo{
+			AuthName:         C.GoString(c.auth_name),
+			Code:             C.GoString(c.code),
+			Name:             C.GoString(c.name),
+			ProjectionMethod: C.GoString(c.projection_method_name),
+			Deprecated:       c.deprecated != 0,
+		}
+		if c.bbox_valid != 0 {
+			info.AreaOfUse = &AreaOfUse{
+				Name:  C.GoString(c.area_name),
+				West:  float64(c.west_lon_degree),
+				South: float64(c.south_lat_degree),
+				East:  float64(c.east_lon_degree),
+				North: float64(c.north_lat_degree),
+			}
+		}
+		crs = append(crs, info)
+	}
+
+	sort.SliceStable(crs, func(i, j int) bool {
+		return crs[i].AreaOfUse.size() < crs[j].AreaOfUse.size()
+	})
+	return crs, nil
+}
+
+// size returns the size of the area in square degree, or +Inf for unknown
+// areas.
+func (a *AreaOfUse) size() float64 {
+	if a == nil {
+		return math.Inf(1)
+	}
+	width := a.East - a.West
+	if width < 0 {
+		// crosses antimeridian
+		width += 360
+	}
+	return width * (a.North - a.South)
+}
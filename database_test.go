@@ -0,0 +1,156 @@
+package proj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckInstallation(t *testing.T) {
+	if err := CheckInstallation(); err != nil {
+		t.Fatal(err)
+	}
+	path, err := DatabasePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "proj.db") {
+		t.Error("unexpected database path", path)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEPSGDatabaseVersion(t *testing.T) {
+	version, err := EPSGDatabaseVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(version, "v") {
+		t.Error("unexpected version", version)
+	}
+}
+
+func TestListUnits(t *testing.T) {
+	var tests = []struct {
+		category UnitCategory
+		name     string
+		factor   float64
+	}{
+		{UnitLinear, "metre", 1},
+		{UnitLinear, "foot", 0.3048},
+		{UnitLinear, "US survey foot", 0.30480060960121924},
+		{UnitAngular, "degree", 0.017453292519943295},
+		{AllUnits, "metre", 1},
+	}
+	for _, tt := range tests {
+		units, err := ListUnits(tt.category)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, u := range units {
+			if tt.category != AllUnits && u.Category != tt.category {
+				t.Errorf("unexpected category %q for %q", u.Category, tt.category)
+				break
+			}
+			if u.Name == tt.name {
+				found = true
+				if !approxEqual(u.ConvFactor, tt.factor, 1e-12) {
+					t.Errorf("unexpected factor %v for %q", u.ConvFactor, u.Name)
+				}
+				if u.AuthName != "EPSG" {
+					t.Errorf("unexpected authority %q for %q", u.AuthName, u.Name)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%q not found in %q units", tt.name, tt.category)
+		}
+	}
+}
+
+func TestValidateEPSG(t *testing.T) {
+	failed, err := ValidateEPSG(4326, 25832, 999999, 3857, 999999, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 2 {
+		t.Fatal("unexpected failures", failed)
+	}
+	if failed[999999] == nil || failed[-1] == nil {
+		t.Error("missing failures", failed)
+	}
+
+	failed, err = ValidateEPSG()
+	if err != nil || len(failed) != 0 {
+		t.Error("unexpected result for no codes", failed, err)
+	}
+}
+
+func TestSuggestCRS(t *testing.T) {
+	crs, err := SuggestCRS(8.1, 53.1, 8.3, 53.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for i, c := range crs {
+		found[c.AuthName+":"+c.Code] = true
+		if c.Deprecated {
+			t.Error("unexpected deprecated CRS", c)
+		}
+		if i > 0 && c.AreaOfUse.size() < crs[i-1].AreaOfUse.size() {
+			t.Error("unexpected order", crs[i-1], c)
+		}
+	}
+	for _, code := range []string{"EPSG:25832", "EPSG:31467", "EPSG:32632"} {
+		if !found[code] {
+			t.Error("missing", code)
+		}
+	}
+	if found["EPSG:25833"] {
+		t.Error("unexpected EPSG:25833 for extent outside of area of use")
+	}
+}
+
+func TestNewEPSGBatch(t *testing.T) {
+	projs, err := NewEPSGBatch([]int{4326, 25832, 999999, 4326})
+	batchErr, ok := err.(*EPSGBatchError)
+	if !ok {
+		t.Fatalf("unexpected error %T %v", err, err)
+	}
+	if len(batchErr.Errors) != 1 || batchErr.Errors[999999] == nil {
+		t.Error("unexpected errors", batchErr.Errors)
+	}
+	if !strings.Contains(err.Error(), "EPSG:999999") {
+		t.Error("unexpected error message", err)
+	}
+	if len(projs) != 2 {
+		t.Fatal("unexpected projections", projs)
+	}
+	// created within the shared lookup context
+	if projs[4326].ctx != nil || projs[25832].ctx != nil {
+		t.Error("dedicated context created")
+	}
+
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := projs[4326].Transform(projs[25832], pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+	// projections are independent
+	projs[4326].Free()
+	if _, err := projs[25832].Name(); err != nil {
+		t.Error(err)
+	}
+
+	projs, err = NewEPSGBatch([]int{3857})
+	if err != nil || len(projs) != 1 {
+		t.Error("unexpected result", projs, err)
+	}
+}
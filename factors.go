@@ -0,0 +1,63 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// AreaDistortionStats returns the minimum, maximum and mean areal scale
+// factor of the projection within the bounding box (in degree, longitude and
+// latitude). The factors are computed with proj_factors for nx*ny points,
+// evenly sampled on a grid that includes the corners of the bounding box. An
+// areal scale of 1 means that there is no area distortion. The bounding box
+// crosses the antimeridian if west > east.
+//
+// Returns an error if p is not a projected CRS.
+func (p *Proj) AreaDistortionStats(west, south, east, north float64, nx, ny int) (min, max, mean float64, err error) {
+	if nx < 1 || ny < 1 {
+		return 0, 0, 0, errors.New("nx and ny need to be at least 1")
+	}
+	if C.proj_get_type(p.p) != C.PJ_TYPE_PROJECTED_CRS {
+		return 0, 0, 0, errors.New("projection is not a projected CRS")
+	}
+	if west > east {
+		east += 360
+	}
+
+	ctx := p.context()
+	min, max = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for j := 0; j < ny; j++ {
+		lat := sampleAt(south, north, j, ny)
+		for i := 0; i < nx; i++ {
+			lon := sampleAt(west, east, i, nx)
+			if lon > 180 {
+				lon -= 360
+			}
+			c := Coord{X: lon * math.Pi / 180, Y: lat * math.Pi / 180}
+			f := C.proj_factors(p.p, *(*C.PJ_COORD)(unsafe.Pointer(&c)))
+			scale := float64(f.areal_scale)
+			if scale <= 0 || math.IsNaN(scale) {
+				return 0, 0, 0, fmt.Errorf("computing factors at %g/%g: %w", lon, lat, ctxError(ctx))
+			}
+			min = math.Min(min, scale)
+			max = math.Max(max, scale)
+			sum += scale
+		}
+	}
+	return min, max, sum / float64(nx*ny), nil
+}
+
+// sampleAt returns the i-th of n values evenly spaced between from and to
+// (inclusive), or the center if n is 1.
+func sampleAt(from, to float64, i, n int) float64 {
+	if n == 1 {
+		return (from + to) / 2
+	}
+	return from + (to-from)*float64(i)/float64(n-1)
+}
@@ -0,0 +1,63 @@
+package proj
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAreaDistortionStats(t *testing.T) {
+	utm, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer utm.Free()
+
+	// UTM has a scale of 0.9996 at the central meridian (9°E)
+	min, max, mean, err := utm.AreaDistortionStats(6, 47, 12, 55, 7, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min < 0.999 || max > 1.002 || min >= max || mean < min || mean > max {
+		t.Error("unexpected stats", min, max, mean)
+	}
+
+	// areal scale of Mercator is 1/cos²(lat), 4 at 60°N
+	merc, err := NewEPSG(3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer merc.Free()
+	min, max, mean, err = merc.AreaDistortionStats(-10, 60, 10, 60, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{min, max, mean} {
+		if math.Abs(v-4) > 1e-3 {
+			t.Error("unexpected areal scale", v)
+		}
+	}
+
+	geo, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer geo.Free()
+	if _, _, _, err := geo.AreaDistortionStats(6, 47, 12, 55, 2, 2); err == nil {
+		t.Error("no error for geographic CRS")
+	}
+	if _, _, _, err := utm.AreaDistortionStats(6, 47, 12, 55, 0, 2); err == nil {
+		t.Error("no error for invalid grid size")
+	}
+}
+
+func TestSampleAt(t *testing.T) {
+	if v := sampleAt(10, 20, 0, 1); v != 15 {
+		t.Error(v)
+	}
+	if v := sampleAt(10, 20, 0, 3); v != 10 {
+		t.Error(v)
+	}
+	if v := sampleAt(10, 20, 2, 3); v != 20 {
+		t.Error(v)
+	}
+}
@@ -0,0 +1,110 @@
+package proj
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// TransformGeoJSON transforms all coordinates of a GeoJSON geometry (Point,
+// LineString, Polygon, MultiPoint, MultiLineString, MultiPolygon or
+// GeometryCollection) and returns the transformed geometry. All other
+// members of the geometry are kept.
+//
+// Positions are transformed in the axis order of the transformer. Call
+// NormalizeForVisualization for GeoJSON data in lon/lat or x/y order.
+func (t *Transformer) TransformGeoJSON(raw json.RawMessage) (json.RawMessage, error) {
+	var geom map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &geom); err != nil {
+		return nil, err
+	}
+	var typ string
+	if err := json.Unmarshal(geom["type"], &typ); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON geometry type: %w", err)
+	}
+
+	var err error
+	switch typ {
+	case "GeometryCollection":
+		var geoms []json.RawMessage
+		if err := json.Unmarshal(geom["geometries"], &geoms); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON geometries: %w", err)
+		}
+		for i := range geoms {
+			if geoms[i], err = t.TransformGeoJSON(geoms[i]); err != nil {
+				return nil, err
+			}
+		}
+		geom["geometries"], err = json.Marshal(geoms)
+	case "Point":
+		geom["coordinates"], err = t.transformPositions(geom["coordinates"], 0)
+	case "LineString", "MultiPoint":
+		geom["coordinates"], err = t.transformPositions(geom["coordinates"], 1)
+	case "Polygon", "MultiLineString":
+		geom["coordinates"], err = t.transformPositions(geom["coordinates"], 2)
+	case "MultiPolygon":
+		geom["coordinates"], err = t.transformPositions(geom["coordinates"], 3)
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON geometry type %q", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geom)
+}
+
+// transformPositions transforms a GeoJSON coordinates array with depth
+// nested arrays of positions (0 for a single position).
+func (t *Transformer) transformPositions(raw json.RawMessage, depth int) (json.RawMessage, error) {
+	if raw == nil {
+		return nil, errors.New("missing GeoJSON coordinates")
+	}
+
+	// Collect all position arrays, to transform them in a single call.
+	var parts [][]Coord
+	var coords interface{}
+	switch depth {
+	case 0:
+		pt := make([]Coord, 1)
+		if err := json.Unmarshal(raw, &pt[0]); err != nil {
+			return nil, err
+		}
+		parts = append(parts, pt)
+		coords = &pt[0]
+	case 1:
+		var line []Coord
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return nil, err
+		}
+		parts = append(parts, line)
+		coords = line
+	case 2:
+		var lines [][]Coord
+		if err := json.Unmarshal(raw, &lines); err != nil {
+			return nil, err
+		}
+		parts = append(parts, lines...)
+		coords = lines
+	case 3:
+		var polys [][][]Coord
+		if err := json.Unmarshal(raw, &polys); err != nil {
+			return nil, err
+		}
+		for _, poly := range polys {
+			parts = append(parts, poly...)
+		}
+		coords = polys
+	}
+
+	var all []Coord
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	if err := t.Transform(all); err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		all = all[copy(part, all):]
+	}
+	return json.Marshal(coords)
+}
@@ -0,0 +1,94 @@
+package proj
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTransformGeoJSON(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		geojson  string
+		expected string
+	}{
+		{`{"type": "Point", "coordinates": [10, 0]}`,
+			`{"coordinates":[1113194.91,0],"type":"Point"}`},
+		{`{"type": "Point", "coordinates": [10, 0, 42], "bbox": [10, 0, 10, 0]}`,
+			`{"bbox":[10,0,10,0],"coordinates":[1113194.91,0,42],"type":"Point"}`},
+		{`{"type": "LineString", "coordinates": [[10, 0], [-10, 0]]}`,
+			`{"coordinates":[[1113194.91,0],[-1113194.91,0]],"type":"LineString"}`},
+		{`{"type": "MultiPoint", "coordinates": [[10, 0]]}`,
+			`{"coordinates":[[1113194.91,0]],"type":"MultiPoint"}`},
+		{`{"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [0, 0]], [[-10, 0], [0, 0]]]}`,
+			`{"coordinates":[[[0,0],[1113194.91,0],[0,0]],[[-1113194.91,0],[0,0]]],"type":"Polygon"}`},
+		{`{"type": "MultiLineString", "coordinates": [[[10, 0]], [[-10, 0], [0, 0]]]}`,
+			`{"coordinates":[[[1113194.91,0]],[[-1113194.91,0],[0,0]]],"type":"MultiLineString"}`},
+		{`{"type": "MultiPolygon", "coordinates": [[[[10, 0], [0, 0]]], [[[-10, 0]], [[0, 0]]]]}`,
+			`{"coordinates":[[[[1113194.91,0],[0,0]]],[[[-1113194.91,0]],[[0,0]]]],"type":"MultiPolygon"}`},
+		{`{"type": "GeometryCollection", "geometries": [{"type": "Point", "coordinates": [10, 0]}, {"type": "LineString", "coordinates": [[-10, 0]]}]}`,
+			`{"geometries":[{"coordinates":[1113194.91,0],"type":"Point"},{"coordinates":[[-1113194.91,0]],"type":"LineString"}],"type":"GeometryCollection"}`},
+	}
+	for _, tt := range tests {
+		result, err := transf.TransformGeoJSON(json.RawMessage(tt.geojson))
+		if err != nil {
+			t.Errorf("%s for %s", err, tt.geojson)
+			continue
+		}
+		if rounded := roundGeoJSON(t, result); rounded != tt.expected {
+			t.Errorf("%s != %s", rounded, tt.expected)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"type": "Point"}`,
+		`{"type": "Point", "coordinates": [[10, 0]]}`,
+		`{"type": "Feature", "geometry": {"type": "Point", "coordinates": [10, 0]}}`,
+		`{"coordinates": [10, 0]}`,
+		`{"type": "GeometryCollection"}`,
+		`[10, 0]`,
+	} {
+		if _, err := transf.TransformGeoJSON(json.RawMessage(invalid)); err == nil {
+			t.Errorf("no error for %s", invalid)
+		}
+	}
+}
+
+// roundGeoJSON rounds all numbers of the GeoJSON to two decimals.
+func roundGeoJSON(t *testing.T, raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatal(err)
+	}
+	var round func(v interface{}) interface{}
+	round = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case float64:
+			r := float64(int64(v*100+0.5)) / 100
+			if v < 0 {
+				r = float64(int64(v*100-0.5)) / 100
+			}
+			return r
+		case []interface{}:
+			for i := range v {
+				v[i] = round(v[i])
+			}
+		case map[string]interface{}:
+			for k := range v {
+				v[k] = round(v[k])
+			}
+		}
+		return v
+	}
+	data, err := json.Marshal(round(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -1,3 +1,3 @@
 module github.com/omniscale/go-proj/v2
 
-go 1.12
+go 1.13
@@ -0,0 +1,43 @@
+package proj
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// MarshalJSON encodes the coordinate as GeoJSON position. The coordinate is
+// encoded as [x, y] if Z is 0, or as [x, y, z] otherwise. T is only
+// included ([x, y, z, t]) if it is neither 0 nor unset (math.MaxFloat64, see
+// XY).
+func (c Coord) MarshalJSON() ([]byte, error) {
+	pos := []float64{c.X, c.Y}
+	hasT := c.T != 0 && c.T != math.MaxFloat64
+	if c.Z != 0 || hasT {
+		pos = append(pos, c.Z)
+	}
+	if hasT {
+		pos = append(pos, c.T)
+	}
+	return json.Marshal(pos)
+}
+
+// UnmarshalJSON decodes a GeoJSON position with two to four elements.
+// Missing Z is set to 0, missing T is set to math.MaxFloat64 (see XY).
+func (c *Coord) UnmarshalJSON(data []byte) error {
+	var pos []float64
+	if err := json.Unmarshal(data, &pos); err != nil {
+		return err
+	}
+	if len(pos) < 2 || len(pos) > 4 {
+		return fmt.Errorf("invalid position with %d elements", len(pos))
+	}
+	*c = XY(pos[0], pos[1])
+	if len(pos) > 2 {
+		c.Z = pos[2]
+	}
+	if len(pos) > 3 {
+		c.T = pos[3]
+	}
+	return nil
+}
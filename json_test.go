@@ -0,0 +1,70 @@
+package proj
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestCoordMarshalJSON(t *testing.T) {
+	var tests = []struct {
+		c    Coord
+		json string
+	}{
+		{XY(8.15, 53.2), `[8.15,53.2]`},
+		{Coord{X: 8.15, Y: 53.2}, `[8.15,53.2]`},
+		{Coord{X: 8.15, Y: 53.2, Z: 10, T: math.MaxFloat64}, `[8.15,53.2,10]`},
+		{Coord{X: 8.15, Y: 53.2, Z: 10, T: 2020.5}, `[8.15,53.2,10,2020.5]`},
+		{Coord{X: 8.15, Y: 53.2, T: 2020.5}, `[8.15,53.2,0,2020.5]`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.c)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != tt.json {
+			t.Errorf("%s != %s for %v", data, tt.json, tt.c)
+		}
+	}
+
+	data, err := json.Marshal([]Coord{XY(1, 2), XY(3, 4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[[1,2],[3,4]]` {
+		t.Error("unexpected json", string(data))
+	}
+
+	if _, err := json.Marshal(XY(math.NaN(), 1)); err == nil {
+		t.Error("no error for NaN")
+	}
+}
+
+func TestCoordUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		json string
+		c    Coord
+	}{
+		{`[8.15,53.2]`, XY(8.15, 53.2)},
+		{`[8.15, 53.2, 10]`, Coord{X: 8.15, Y: 53.2, Z: 10, T: math.MaxFloat64}},
+		{`[8.15,53.2,10,2020.5]`, Coord{X: 8.15, Y: 53.2, Z: 10, T: 2020.5}},
+	}
+	for _, tt := range tests {
+		var c Coord
+		if err := json.Unmarshal([]byte(tt.json), &c); err != nil {
+			t.Error(err)
+			continue
+		}
+		if c != tt.c {
+			t.Errorf("%v != %v for %s", c, tt.c, tt.json)
+		}
+	}
+
+	for _, invalid := range []string{`[]`, `[1]`, `[1,2,3,4,5]`, `{"x": 1}`, `["1", "2"]`} {
+		var c Coord
+		if err := json.Unmarshal([]byte(invalid), &c); err == nil {
+			t.Errorf("no error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"sync"
+)
+
+// LogLevel is the level of the log messages that PROJ writes to stderr.
+type LogLevel int
+
+const (
+	// LogNone disables all log messages of PROJ (default).
+	LogNone LogLevel = iota
+	LogError
+	LogDebug
+	LogTrace
+)
+
+func (l LogLevel) pjLogLevel() C.PJ_LOG_LEVEL {
+	switch l {
+	case LogError:
+		return C.PJ_LOG_ERROR
+	case LogDebug:
+		return C.PJ_LOG_DEBUG
+	case LogTrace:
+		return C.PJ_LOG_TRACE
+	default:
+		return C.PJ_LOG_NONE
+	}
+}
+
+// SetLogLevel sets the level of the log messages that PROJ writes to
+// stderr, for all existing and future projections and transformations.
+func SetLogLevel(level LogLevel) {
+	contexts.setLevel(level)
+}
+
+// CurrentLogLevel returns the log level set by SetLogLevel.
+func CurrentLogLevel() LogLevel {
+	contexts.mu.Lock()
+	defer contexts.mu.Unlock()
+	return contexts.level
+}
+
+// logLevel returns the log level of the context of the projection.
+func (p *Proj) logLevel() LogLevel {
+	switch C.proj_log_level(p.context(), C.PJ_LOG_TELL) {
+	case C.PJ_LOG_ERROR:
+		return LogError
+	case C.PJ_LOG_DEBUG:
+		return LogDebug
+	case C.PJ_LOG_TRACE:
+		return LogTrace
+	default:
+		return LogNone
+	}
+}
+
+// contexts keeps track of all contexts created by newContext, so that
+// SetLogLevel can update existing contexts.
+var contexts = &contextRegistry{
+	all: make(map[*C.PJ_CONTEXT]struct{}),
+}
+
+type contextRegistry struct {
+	mu    sync.Mutex
+	all   map[*C.PJ_CONTEXT]struct{}
+	level LogLevel
+}
+
+func (r *contextRegistry) register(ctx *C.PJ_CONTEXT) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	C.proj_log_level(ctx, r.level.pjLogLevel())
+	r.all[ctx] = struct{}{}
+}
+
+func (r *contextRegistry) setLevel(level LogLevel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.level = level
+	for ctx := range r.all {
+		C.proj_log_level(ctx, level.pjLogLevel())
+	}
+}
+
+// destroyContext destroys a context created by newContext.
+func destroyContext(ctx *C.PJ_CONTEXT) {
+	contexts.mu.Lock()
+	delete(contexts.all, ctx)
+	contexts.mu.Unlock()
+	C.proj_context_destroy(ctx)
+}
@@ -0,0 +1,44 @@
+package proj
+
+import (
+	"testing"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	defer SetLogLevel(LogNone)
+
+	p, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CurrentLogLevel() != LogNone {
+		t.Error("unexpected default log level", CurrentLogLevel())
+	}
+
+	SetLogLevel(LogDebug)
+	if CurrentLogLevel() != LogDebug {
+		t.Error("log level not set", CurrentLogLevel())
+	}
+	// existing context
+	if l := p.logLevel(); l != LogDebug {
+		t.Error("log level of existing projection not set", l)
+	}
+	// new context
+	p2, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := p2.logLevel(); l != LogDebug {
+		t.Error("log level of new projection not set", l)
+	}
+
+	ctx := p2.ctx
+	p.Free()
+	p2.Free()
+	contexts.mu.Lock()
+	_, ok := contexts.all[ctx]
+	contexts.mu.Unlock()
+	if ok {
+		t.Error("freed context still registered")
+	}
+}
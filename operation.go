@@ -0,0 +1,665 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// OperationDescription describes a coordinate operation between two
+// projections.
+type OperationDescription struct {
+	// Name of the operation, e.g. "Inverse of ETRS89 to WGS 84 (1) + UTM zone 32N".
+	Name string
+	// Accuracy of the operation in metre, or -1 if unknown.
+	Accuracy float64
+	// Ballpark is true if the operation uses a ballpark transformation
+	// (e.g. without datum shift), with an unknown accuracy.
+	Ballpark bool
+	// Grids used by the operation.
+	Grids []GridInfo
+	// AreaOfUse of the operation, or nil if unknown.
+	AreaOfUse *AreaOfUse
+}
+
+// GridInfo describes a grid file used by a coordinate operation.
+type GridInfo struct {
+	ShortName   string
+	FullName    string
+	PackageName string
+	URL         string
+	// DirectDownload is true if the grid can be downloaded from URL.
+	DirectDownload bool
+	OpenLicense    bool
+	// Available is true if the grid is available locally.
+	Available bool
+}
+
+// AreaOfUse is the geographic area (in degree) where a projection or
+// operation is valid.
+type AreaOfUse struct {
+	Name                     string
+	West, South, East, North float64
+}
+
+// Describe returns a description of the coordinate operation that is
+// preferred to transform from Src to Dst. The description is cached after
+// the first call.
+func (t *Transformer) Describe() (OperationDescription, error) {
+	if t.desc != nil {
+		return *t.desc, nil
+	}
+	op, err := t.operation()
+	if err != nil {
+		return OperationDescription{}, err
+	}
+	defer C.proj_destroy(op)
+
+	desc := describeOperation(t.Src.context(), op)
+	t.desc = &desc
+	return desc, nil
+}
+
+// LastUsedOperation returns a description of the coordinate operation that
+// was used to transform the last coordinate of the last Transform call. The
+// operation can differ from the one returned by Describe and between
+// coordinates, if Src and Dst are related by multiple operations for
+// different areas (e.g. grids that only cover parts of a country).
+// Requires TransformOptions.RecordLastUsedOperation. Returns an error for
+// PROJ versions before 9.1.
+func (t *Transformer) LastUsedOperation() (OperationDescription, error) {
+	if !t.opts.RecordLastUsedOperation {
+		return OperationDescription{}, errors.New("RecordLastUsedOperation is not enabled")
+	}
+	if t.lastOp == nil {
+		return OperationDescription{}, errors.New("no coordinates transformed")
+	}
+	return t.lastOp.describe()
+}
+
+// usedOperation is the coordinate operation that was used by the last
+// Transform of a Transformer. The operation is assigned to the shared lookup
+// context, as the context of the transformation can be destroyed while the
+// Transformer is still in use.
+type usedOperation struct {
+	pj *C.PJ // nil if unknown
+}
+
+// newUsedOperation returns a new usedOperation that takes ownership of pj.
+func newUsedOperation(pj *C.PJ) *usedOperation {
+	op := &usedOperation{pj: pj}
+	if pj != nil {
+		lookup.Lock()
+		C.proj_assign_context(pj, lookupContext())
+		lookup.Unlock()
+		runtime.SetFinalizer(op, (*usedOperation).free)
+	}
+	return op
+}
+
+func (o *usedOperation) describe() (OperationDescription, error) {
+	if o.pj == nil {
+		if !hasLastUsedOperation() {
+			return OperationDescription{}, unsupportedError("LastUsedOperation", "9.1")
+		}
+		return OperationDescription{}, errors.New("last used operation is unknown")
+	}
+	lookup.Lock()
+	defer lookup.Unlock()
+	return describeOperation(lookup.ctx, o.pj), nil
+}
+
+func (o *usedOperation) free() {
+	lookup.Lock()
+	defer lookup.Unlock()
+	C.proj_destroy(o.pj)
+	o.pj = nil
+}
+
+// MissingGrids returns all grids that are required for the most accurate
+// coordinate operation from Src to Dst, but that are not available locally.
+// Transform falls back to less accurate operations (e.g. ballpark
+// transformations) if grids are missing. See GridInfo.URL for the download
+// location of the grids.
+func (t *Transformer) MissingGrids() ([]GridInfo, error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	return t.missingGrids(t.Src.context())
+}
+
+// AvailableOffline returns whether all grids that are required for the most
+// accurate coordinate operation from Src to Dst are available locally,
+// without downloading them from the network (see MissingGrids).
+func (t *Transformer) AvailableOffline() (bool, error) {
+	if t.Src == nil || t.Dst == nil {
+		return false, errors.New("missing/invalid projection")
+	}
+	ctx := newContext()
+	defer destroyContext(ctx)
+	C.proj_context_set_enable_network(ctx, 0)
+
+	missing, err := t.missingGrids(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(missing) == 0, nil
+}
+
+// missingGrids returns the grids of the most accurate coordinate operation
+// that are not available with the context ctx.
+func (t *Transformer) missingGrids(ctx *C.PJ_CONTEXT) ([]GridInfo, error) {
+	opts := t.opts
+	opts.ignoreGridAvailability = true
+	var op *C.PJ
+	var err error
+	if opts.pipeline != "" {
+		op, err = createTransformation(ctx, t.Src, t.Dst, opts)
+	} else {
+		op, err = preferredOperation(ctx, t.Src, t.Dst, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer C.proj_destroy(op)
+
+	var missing []GridInfo
+	for _, g := range operationGrids(ctx, op) {
+		if !g.Available {
+			missing = append(missing, g)
+		}
+	}
+	return missing, nil
+}
+
+// GDALProjString returns the preferred coordinate operation from Src to Dst
+// as a proj string (e.g. "+proj=pipeline +step ..."), as accepted by GDAL
+// (e.g. gdalwarp -ct).
+func (t *Transformer) GDALProjString() (string, error) {
+	op, err := t.operation()
+	if err != nil {
+		return "", err
+	}
+	defer C.proj_destroy(op)
+
+	ctx := t.Src.context()
+	s := C.proj_as_proj_string(ctx, op, C.PJ_PROJ_5, nil)
+	if s == nil {
+		return "", ctxError(ctx)
+	}
+	return C.GoString(s), nil
+}
+
+// PipelineOption is a candidate coordinate operation of PipelineOptions.
+type PipelineOption struct {
+	// Pipeline is the operation as a proj string (e.g. "+proj=pipeline
+	// +step ...").
+	Pipeline string
+	// Accuracy of the operation in metre, or -1 if unknown.
+	Accuracy float64
+}
+
+// PipelineOptions returns all coordinate operations from srcEPSG to dstEPSG
+// as proj strings, sorted by accuracy (most accurate first). Operations with
+// an unknown accuracy are returned last. Operations are included regardless
+// of whether the required grids are available. Operations that can not be
+// represented as a proj string are omitted.
+func PipelineOptions(srcEPSG, dstEPSG int) ([]PipelineOption, error) {
+	src, err := NewEPSG(srcEPSG)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Free()
+	dst, err := NewEPSG(dstEPSG)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Free()
+
+	ctx := src.context()
+	ops, err := createOperations(ctx, src, dst, TransformOptions{ignoreGridAvailability: true})
+	if err != nil {
+		return nil, err
+	}
+	defer C.proj_list_destroy(ops)
+
+	var result []PipelineOption
+	n := int(C.proj_list_get_count(ops))
+	for i := 0; i < n; i++ {
+		op := C.proj_list_get(ctx, ops, C.int(i))
+		if op == nil {
+			return nil, ctxError(ctx)
+		}
+		s := C.proj_as_proj_string(ctx, op, C.PJ_PROJ_5, nil)
+		if s != nil {
+			result = append(result, PipelineOption{
+				Pipeline: C.GoString(s),
+				Accuracy: float64(C.proj_coordoperation_get_accuracy(ctx, op)),
+			})
+		}
+		C.proj_destroy(op)
+	}
+
+	// stable, to keep the order of PROJ for operations with equal accuracy
+	sort.SliceStable(result, func(i, j int) bool {
+		ai, aj := result[i].Accuracy, result[j].Accuracy
+		if ai < 0 || aj < 0 {
+			return ai >= 0 && aj < 0
+		}
+		return ai < aj
+	})
+	return result, nil
+}
+
+// Report returns a human-readable, multi-line description of the transformer
+// with the source and target CRS and the name, accuracy, grids and area of
+// use of the preferred coordinate operation (see Describe).
+func (t *Transformer) Report() (string, error) {
+	desc, err := t.Describe()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source CRS: %s\n", t.Src.reportName())
+	fmt.Fprintf(&b, "Target CRS: %s\n", t.Dst.reportName())
+	fmt.Fprintf(&b, "Operation: %s\n", desc.Name)
+	switch {
+	case desc.Ballpark:
+		b.WriteString("Accuracy: unknown (ballpark transformation)\n")
+	case desc.Accuracy < 0:
+		b.WriteString("Accuracy: unknown\n")
+	default:
+		fmt.Fprintf(&b, "Accuracy: %g m\n", desc.Accuracy)
+	}
+	if len(desc.Grids) == 0 {
+		b.WriteString("Grids: none\n")
+	} else {
+		b.WriteString("Grids:\n")
+		for _, g := range desc.Grids {
+			status := "available"
+			if !g.Available {
+				status = "missing"
+			}
+			fmt.Fprintf(&b, "  %s (%s)\n", g.ShortName, status)
+		}
+	}
+	if a := desc.AreaOfUse; a != nil {
+		fmt.Fprintf(&b, "Area of use: %s (%g, %g, %g, %g)\n", a.Name, a.West, a.South, a.East, a.North)
+	} else {
+		b.WriteString("Area of use: unknown\n")
+	}
+	return b.String(), nil
+}
+
+// reportName returns the name of the CRS with the authority code, e.g.
+// "WGS 84 (EPSG:4326)".
+func (p *Proj) reportName() string {
+	name, err := p.Name()
+	if err != nil {
+		name = p.Description()
+	}
+	auth, code := objectID(p.p)
+	if auth == "" || code == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s:%s)", name, auth, code)
+}
+
+// objectID returns the first identifier of obj (e.g. "EPSG" and "4326"), or
+// empty strings if obj has no identifier.
+func objectID(obj *C.PJ) (auth, code string) {
+	cAuth := C.proj_get_id_auth_name(obj, 0)
+	cCode := C.proj_get_id_code(obj, 0)
+	if cAuth == nil || cCode == nil {
+		return "", ""
+	}
+	return C.GoString(cAuth), C.GoString(cCode)
+}
+
+// EndpointCodes returns the identifiers of the source and target CRS of the
+// preferred coordinate operation from Src to Dst (e.g. "EPSG" and "4326").
+// The CRS of the operation can differ from Src and Dst, if PROJ uses an
+// equivalent CRS. The authority and code are empty if the CRS has no
+// identifier (e.g. for CRS from proj strings).
+func (t *Transformer) EndpointCodes() (srcAuth, srcCode, dstAuth, dstCode string, err error) {
+	op, err := t.operation()
+	if err != nil {
+		return "", "", "", "", err
+	}
+	defer C.proj_destroy(op)
+
+	ctx := t.Src.context()
+	src := C.proj_get_source_crs(ctx, op)
+	if src == nil {
+		return "", "", "", "", ctxError(ctx)
+	}
+	defer C.proj_destroy(src)
+	dst := C.proj_get_target_crs(ctx, op)
+	if dst == nil {
+		return "", "", "", "", ctxError(ctx)
+	}
+	defer C.proj_destroy(dst)
+
+	srcAuth, srcCode = objectID(src)
+	dstAuth, dstCode = objectID(dst)
+	return srcAuth, srcCode, dstAuth, dstCode, nil
+}
+
+// ChainTransformers returns a single transformer that transforms coordinates
+// like all transformers applied one after another. The Dst of each
+// transformer needs to be equivalent to the Src of the next transformer.
+// The preferred coordinate operation of each transformer is concatenated
+// into a single pipeline, so that coordinates are transformed in one pass.
+// The operation of each transformer is selected with its TransformOptions
+// (e.g. PivotCRS).
+//
+// The returned transformer uses ClampLatitude, OnTransform and all other
+// TransformOptions (e.g. ValidateGeographic) of the first transformer, and
+// TransformOptions.LonWrap of the last transformer. Returns an error if one
+// of the other transformers sets these, as they would not apply to the
+// chain.
+func ChainTransformers(t ...Transformer) (Transformer, error) {
+	if len(t) == 0 {
+		return Transformer{}, errors.New("no transformers to chain")
+	}
+	var steps []string
+	for i := range t {
+		if i > 0 && !t[i-1].Dst.isEquivalentTo(t[i].Src) {
+			return Transformer{}, fmt.Errorf("Dst of transformer %d does not match Src of transformer %d", i-1, i)
+		}
+		if i > 0 && (t[i].ClampLatitude != 0 || t[i].OnTransform != nil || t[i].opts.hasTransformSettings()) {
+			return Transformer{}, fmt.Errorf("transformer %d sets options that only apply to the first transformer of a chain", i)
+		}
+		if i < len(t)-1 && t[i].opts.LonWrap != 0 {
+			return Transformer{}, fmt.Errorf("transformer %d sets LonWrap, which only applies to the last transformer of a chain", i)
+		}
+		s, err := t[i].GDALProjString()
+		if err != nil {
+			return Transformer{}, err
+		}
+		opSteps, err := pipelineSteps(s)
+		if err != nil {
+			return Transformer{}, err
+		}
+		steps = append(steps, opSteps...)
+	}
+	first := t[0].opts
+	return Transformer{
+		Src:           t[0].Src,
+		Dst:           t[len(t)-1].Dst,
+		ClampLatitude: t[0].ClampLatitude,
+		OnTransform:   t[0].OnTransform,
+		opts: TransformOptions{
+			RejectNonFinite:         first.RejectNonFinite,
+			ValidateGeographic:      first.ValidateGeographic,
+			MinLongitude:            first.MinLongitude,
+			MaxLongitude:            first.MaxLongitude,
+			LonWrap:                 t[len(t)-1].opts.LonWrap,
+			NetworkRetries:          first.NetworkRetries,
+			NetworkRetryBackoff:     first.NetworkRetryBackoff,
+			RecordLastUsedOperation: first.RecordLastUsedOperation,
+			pipeline:                "+proj=pipeline " + strings.Join(steps, " "),
+		},
+	}, nil
+}
+
+// Inverse returns a transformer from Dst to Src. The returned transformer
+// shares the projections with t.
+func (t *Transformer) Inverse() Transformer {
+	inv := Transformer{
+		Src:           t.Dst,
+		Dst:           t.Src,
+		ClampLatitude: t.ClampLatitude,
+		OnTransform:   t.OnTransform,
+		opts:          t.opts,
+	}
+	if t.opts.pipeline != "" {
+		// Keep the pipeline and apply it in the other direction.
+		inv.opts.invertPipeline = !t.opts.invertPipeline
+	}
+	return inv
+}
+
+// pipelineSteps splits the proj string of an operation into single steps
+// (e.g. "+step +proj=utm +zone=32"). Returns an error for pipelines with
+// global options.
+func pipelineSteps(s string) ([]string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.New("empty operation")
+	}
+	if fields[0] != "+proj=pipeline" {
+		return []string{"+step " + strings.Join(fields, " ")}, nil
+	}
+	fields = fields[1:]
+
+	var steps []string
+	for _, f := range fields {
+		if f == "+step" {
+			steps = append(steps, f)
+			continue
+		}
+		if len(steps) == 0 {
+			return nil, fmt.Errorf("unable to concatenate pipeline with global option %q", f)
+		}
+		steps[len(steps)-1] += " " + f
+	}
+	return steps, nil
+}
+
+// PipelineStep is a single step of a transformation pipeline.
+type PipelineStep struct {
+	// Proj is the name of the operation (e.g. "utm" or "helmert").
+	Proj string
+	// Inverse is true if the operation is applied in inverse direction.
+	Inverse bool
+	// Params of the operation, without proj and inv.
+	Params []PipelineParam
+}
+
+// PipelineParam is a parameter of a PipelineStep. Value is empty for flags
+// (e.g. "no_defs").
+type PipelineParam struct {
+	Key, Value string
+}
+
+func (s PipelineStep) String() string {
+	parts := []string{}
+	if s.Inverse {
+		parts = append(parts, "+inv")
+	}
+	parts = append(parts, "+proj="+s.Proj)
+	for _, p := range s.Params {
+		if p.Value == "" {
+			parts = append(parts, "+"+p.Key)
+		} else {
+			parts = append(parts, "+"+p.Key+"="+p.Value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Steps returns all steps of the preferred coordinate operation from Src to
+// Dst (see GDALProjString).
+func (t *Transformer) Steps() ([]PipelineStep, error) {
+	s, err := t.GDALProjString()
+	if err != nil {
+		return nil, err
+	}
+	return parsePipeline(s)
+}
+
+// SubOperations returns the descriptions of the single operations of the
+// preferred coordinate operation from Src to Dst (see Describe), e.g. "DHDN
+// to ETRS89 (8)" and "UTM zone 32N" for a concatenated operation. Returns
+// the description of the operation itself if it is not a concatenated
+// operation.
+func (t *Transformer) SubOperations() ([]OperationDescription, error) {
+	op, err := t.operation()
+	if err != nil {
+		return nil, err
+	}
+	defer C.proj_destroy(op)
+
+	ctx := t.Src.context()
+	if C.proj_get_type(op) != C.PJ_TYPE_CONCATENATED_OPERATION {
+		return []OperationDescription{describeOperation(ctx, op)}, nil
+	}
+	n := int(C.proj_concatoperation_get_step_count(ctx, op))
+	descs := make([]OperationDescription, 0, n)
+	for i := 0; i < n; i++ {
+		step := C.proj_concatoperation_get_step(ctx, op, C.int(i))
+		if step == nil {
+			return nil, ctxError(ctx)
+		}
+		descs = append(descs, describeOperation(ctx, step))
+		C.proj_destroy(step)
+	}
+	return descs, nil
+}
+
+// parsePipeline parses a proj string of an operation into single steps.
+func parsePipeline(s string) ([]PipelineStep, error) {
+	stepStrings, err := pipelineSteps(s)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]PipelineStep, 0, len(stepStrings))
+	for _, stepString := range stepStrings {
+		step := PipelineStep{}
+		for _, f := range strings.Fields(stepString) {
+			f = strings.TrimPrefix(f, "+")
+			kv := strings.SplitN(f, "=", 2)
+			switch {
+			case kv[0] == "step":
+			case kv[0] == "inv" && len(kv) == 1:
+				step.Inverse = true
+			case kv[0] == "proj" && len(kv) == 2:
+				step.Proj = kv[1]
+			case len(kv) == 2:
+				step.Params = append(step.Params, PipelineParam{Key: kv[0], Value: kv[1]})
+			default:
+				step.Params = append(step.Params, PipelineParam{Key: kv[0]})
+			}
+		}
+		if step.Proj == "" {
+			return nil, fmt.Errorf("missing proj in pipeline step %q", stepString)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// operation returns the preferred coordinate operation from Src to Dst.
+// The returned operation needs to be destroyed by the caller.
+func (t *Transformer) operation() (*C.PJ, error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	ctx := t.Src.context()
+	if t.opts.pipeline == "" {
+		return preferredOperation(ctx, t.Src, t.Dst, t.opts)
+	}
+	op, err := createTransformation(ctx, t.Src, t.Dst, t.opts)
+	if err != nil || !t.opts.invertPipeline {
+		return op, err
+	}
+	inv := C.proj_coordoperation_create_inverse(ctx, op)
+	C.proj_destroy(op)
+	if inv == nil {
+		return nil, ctxError(ctx)
+	}
+	return inv, nil
+}
+
+// describeOperation returns the description of the coordinate operation op.
+func describeOperation(ctx *C.PJ_CONTEXT, op *C.PJ) OperationDescription {
+	desc := OperationDescription{
+		Name:      C.GoString(C.proj_get_name(op)),
+		Accuracy:  float64(C.proj_coordoperation_get_accuracy(ctx, op)),
+		Ballpark:  C.proj_coordoperation_has_ballpark_transformation(ctx, op) != 0,
+		Grids:     operationGrids(ctx, op),
+		AreaOfUse: areaOfUse(ctx, op),
+	}
+	return desc
+}
+
+// operationGrids returns all grids used by the coordinate operation op.
+func operationGrids(ctx *C.PJ_CONTEXT, op *C.PJ) []GridInfo {
+	n := int(C.proj_coordoperation_get_grid_used_count(ctx, op))
+	var grids []GridInfo
+	for i := 0; i < n; i++ {
+		var shortName, fullName, packageName, url *C.char
+		var directDownload, openLicense, available C.int
+		r := C.proj_coordoperation_get_grid_used(ctx, op, C.int(i),
+			&shortName,
+			&fullName,
+			&packageName,
+			&url,
+			&directDownload,
+			&openLicense,
+			&available,
+		)
+		if r == 0 {
+			continue
+		}
+		grids = append(grids, GridInfo{
+			ShortName:      C.GoString(shortName),
+			FullName:       C.GoString(fullName),
+			PackageName:    C.GoString(packageName),
+			URL:            C.GoString(url),
+			DirectDownload: directDownload != 0,
+			OpenLicense:    openLicense != 0,
+			Available:      available != 0,
+		})
+	}
+	return grids
+}
+
+// AreaOfUsePolygon returns the area of use of the CRS as a closed polygon
+// ring with lon/lat coordinates in degree. PROJ only provides the bounding
+// box of the area of use, so the ring contains the five corners of the
+// bounding box, counter-clockwise. The east longitude is greater than 180
+// for areas that cross the antimeridian.
+func (p *Proj) AreaOfUsePolygon() ([]Coord, error) {
+	a := areaOfUse(p.context(), p.p)
+	if a == nil {
+		return nil, errors.New("area of use of projection is unknown")
+	}
+	east := a.East
+	if east < a.West {
+		east += 360
+	}
+	return []Coord{
+		XY(a.West, a.South),
+		XY(east, a.South),
+		XY(east, a.North),
+		XY(a.West, a.North),
+		XY(a.West, a.South),
+	}, nil
+}
+
+// areaOfUse returns the area of use of obj, or nil if it is unknown.
+func areaOfUse(ctx *C.PJ_CONTEXT, obj *C.PJ) *AreaOfUse {
+	var west, south, east, north C.double
+	var name *C.char
+	if C.proj_get_area_of_use(ctx, obj, &west, &south, &east, &north, &name) == 0 {
+		return nil
+	}
+	// PROJ returns -1000 for unknown bounds.
+	if west == -1000 {
+		return nil
+	}
+	return &AreaOfUse{
+		Name:  C.GoString(name),
+		West:  float64(west),
+		South: float64(south),
+		East:  float64(east),
+		North: float64(north),
+	}
+}
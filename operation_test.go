@@ -0,0 +1,530 @@
+package proj
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransformerDescribe(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := transf.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(desc.Name, "UTM zone 32N") {
+		t.Error("unexpected name", desc.Name)
+	}
+	if desc.Ballpark {
+		t.Error("unexpected ballpark transformation")
+	}
+	if desc.Accuracy < 0 {
+		t.Error("unexpected unknown accuracy")
+	}
+	if desc.AreaOfUse == nil || desc.AreaOfUse.Name == "" {
+		t.Error("missing area of use", desc.AreaOfUse)
+	}
+
+	// cached
+	if transf.desc == nil {
+		t.Fatal("description not cached")
+	}
+	cached, err := transf.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Name != desc.Name {
+		t.Error("unexpected cached description", cached)
+	}
+
+	transf = Transformer{}
+	if _, err := transf.Describe(); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
+
+func TestTransformerLastUsedOperation(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{{53.2, 8.15, 0, 0}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if transf.lastOp != nil {
+		t.Error("last used operation recorded without RecordLastUsedOperation")
+	}
+	if _, err := transf.LastUsedOperation(); err == nil {
+		t.Error("no error without RecordLastUsedOperation")
+	}
+
+	transf, err = NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{RecordLastUsedOperation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transf.LastUsedOperation(); err == nil {
+		t.Error("no error before first transformation")
+	}
+
+	pts = []Coord{{53.2, 8.15, 0, 0}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !hasLastUsedOperation() {
+		if _, err := transf.LastUsedOperation(); err == nil {
+			t.Error("no error for unsupported PROJ version")
+		}
+		t.Skip("LastUsedOperation requires PROJ 9.1")
+	}
+	op, err := transf.LastUsedOperation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(op.Name, "UTM zone 32N") {
+		t.Error("unexpected name", op.Name)
+	}
+}
+
+func TestTransformerReport(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := transf.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Source CRS: WGS 84 (EPSG:4326)\n",
+		"Target CRS: ETRS89 / UTM zone 32N (EPSG:25832)\n",
+		"Operation: ",
+		"UTM zone 32N",
+		"Accuracy: ",
+		"Area of use: ",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("missing %q in report:\n%s", expected, report)
+		}
+	}
+
+	transf = Transformer{}
+	if _, err := transf.Report(); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
+
+func TestTransformerEndpointCodes(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcAuth, srcCode, dstAuth, dstCode, err := transf.EndpointCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srcAuth != "EPSG" || srcCode != "4326" || dstAuth != "EPSG" || dstCode != "25832" {
+		t.Error("unexpected codes", srcAuth, srcCode, dstAuth, dstCode)
+	}
+
+	transf = Transformer{}
+	if _, _, _, _, err := transf.EndpointCodes(); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
+
+func TestTransformerGDALProjString(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := transf.GDALProjString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "+proj=pipeline") || !strings.Contains(s, "+proj=utm +zone=32") {
+		t.Error("unexpected proj string", s)
+	}
+}
+
+func TestPipelineOptions(t *testing.T) {
+	// DHDN to ETRS89 / UTM 32N
+	opts, err := PipelineOptions(31467, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) < 2 {
+		t.Fatal("expected multiple operations", opts)
+	}
+	for i, o := range opts {
+		if !strings.HasPrefix(o.Pipeline, "+proj=pipeline") {
+			t.Error("unexpected pipeline", o.Pipeline)
+		}
+		if i > 0 && o.Accuracy >= 0 && (opts[i-1].Accuracy < 0 || opts[i-1].Accuracy > o.Accuracy) {
+			t.Error("not sorted by accuracy", opts[i-1].Accuracy, o.Accuracy)
+		}
+	}
+
+	if _, err := PipelineOptions(4326, 999999); err == nil {
+		t.Error("no error for unknown EPSG code")
+	}
+}
+
+func TestChainTransformers(t *testing.T) {
+	t1, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t2, err := NewEPSGTransformer(25832, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := ChainTransformers(t1, t2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(chain.opts.pipeline, "+proj=pipeline +step") || strings.Count(chain.opts.pipeline, "+proj=pipeline") != 1 {
+		t.Error("unexpected pipeline", chain.opts.pipeline)
+	}
+
+	direct, err := NewEPSGTransformer(4326, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	expected := []Coord{XY(53.2, 8.15)}
+	if err := chain.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if err := direct.Transform(expected); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(expected[0], 0.01) {
+		t.Error(pts, expected)
+	}
+
+	if _, err := ChainTransformers(t2, t1); err == nil {
+		t.Error("no error for mismatching transformers")
+	}
+
+	// settings of the first and last transformer
+	t1, err = NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{ValidateGeographic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1.ClampLatitude = 85
+	t3, err := NewTransformerWithOptions("epsg:25832", "epsg:4326", TransformOptions{LonWrap: 180})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err = ChainTransformers(t1, t3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.ClampLatitude != 85 || !chain.opts.ValidateGeographic || chain.opts.LonWrap != 180 {
+		t.Errorf("settings not carried over %#v", chain)
+	}
+	pts = []Coord{XY(53.2, -8.15)}
+	if err := chain.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 351.85), 1e-5) {
+		t.Error(pts)
+	}
+
+	// settings that would not apply to the chain
+	t4, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ChainTransformers(t3, t4); err == nil {
+		t.Error("no error for LonWrap of first transformer")
+	}
+	t2.ClampLatitude = 85
+	if _, err := ChainTransformers(t1, t2); err == nil {
+		t.Error("no error for ClampLatitude of second transformer")
+	}
+	t2.ClampLatitude = 0
+	t2.OnTransform = func(n int, d time.Duration) {}
+	if _, err := ChainTransformers(t1, t2); err == nil {
+		t.Error("no error for OnTransform of second transformer")
+	}
+	if _, err := ChainTransformers(); err == nil {
+		t.Error("no error for empty chain")
+	}
+}
+
+func TestTransformerInverse(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := transf.Inverse()
+	if inv.Src != transf.Dst || inv.Dst != transf.Src {
+		t.Error("projections not swapped")
+	}
+	pts := []Coord{XY(443220.719, 5894856.508)}
+	if err := inv.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 1e-6) {
+		t.Error(pts)
+	}
+
+	// inverse of chained pipeline
+	t2, err := NewEPSGTransformer(25832, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := ChainTransformers(transf, t2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(53.2, 8.15)}
+	if err := chain.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	invChain := chain.Inverse()
+	if err := invChain.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 1e-6) {
+		t.Error(pts)
+	}
+	// the pipeline is applied in inverse direction
+	if invChain.opts.pipeline != chain.opts.pipeline || !invChain.opts.invertPipeline {
+		t.Error("unexpected pipeline", invChain.opts.pipeline, chain.opts.pipeline)
+	}
+	if inv := invChain.Inverse(); inv.opts.pipeline != chain.opts.pipeline || inv.opts.invertPipeline {
+		t.Error("unexpected pipeline", inv.opts.pipeline, chain.opts.pipeline)
+	}
+	if _, err := invChain.GDALProjString(); err != nil {
+		t.Error(err)
+	}
+	f, err := invChain.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := f(XY(907253.850, 7020078.533))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pt.ApproxEqual(XY(53.2, 8.15), 1e-5) {
+		t.Error(pt)
+	}
+}
+
+func TestPipelineSteps(t *testing.T) {
+	var tests = []struct {
+		s     string
+		steps []string
+	}{
+		{"+proj=utm +zone=32 +ellps=GRS80", []string{"+step +proj=utm +zone=32 +ellps=GRS80"}},
+		{"+proj=pipeline +step +proj=axisswap +order=2,1 +step +inv +proj=utm +zone=32",
+			[]string{"+step +proj=axisswap +order=2,1", "+step +inv +proj=utm +zone=32"}},
+		{"+proj=pipeline +ellps=GRS80 +step +proj=utm +zone=32", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		steps, err := pipelineSteps(tt.s)
+		if tt.steps == nil {
+			if err == nil {
+				t.Errorf("no error for %q", tt.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if strings.Join(steps, "|") != strings.Join(tt.steps, "|") {
+			t.Errorf("unexpected steps %q for %q", steps, tt.s)
+		}
+	}
+}
+
+func TestTransformerSteps(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := transf.Steps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) < 2 {
+		t.Fatal("unexpected steps", steps)
+	}
+	last := steps[len(steps)-1]
+	if last.Proj != "utm" || last.Inverse {
+		t.Error("unexpected last step", last)
+	}
+	found := false
+	for _, p := range last.Params {
+		if p.Key == "zone" && p.Value == "32" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("zone not found in", last)
+	}
+}
+
+func TestTransformerSubOperations(t *testing.T) {
+	// DHDN / GK 3 to ETRS89 / UTM 32N
+	transf, err := NewEPSGTransformer(31467, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := transf.SubOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) < 3 {
+		t.Fatal("expected concatenated operation", ops)
+	}
+	if !strings.Contains(ops[0].Name, "Gauss-Kruger") || !strings.Contains(ops[len(ops)-1].Name, "UTM zone 32N") {
+		t.Error("unexpected operations", ops[0].Name, ops[len(ops)-1].Name)
+	}
+
+	// single conversion
+	transf, err = NewEPSGTransformer(4326, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err = transf.SubOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Error("unexpected operations", ops)
+	}
+}
+
+func TestParsePipeline(t *testing.T) {
+	steps, err := parsePipeline("+proj=pipeline +step +proj=axisswap +order=2,1 +step +proj=unitconvert +xy_in=deg +xy_out=rad +step +inv +proj=utm +zone=32 +south +ellps=GRS80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PipelineStep{
+		{Proj: "axisswap", Params: []PipelineParam{{"order", "2,1"}}},
+		{Proj: "unitconvert", Params: []PipelineParam{{"xy_in", "deg"}, {"xy_out", "rad"}}},
+		{Proj: "utm", Inverse: true, Params: []PipelineParam{{"zone", "32"}, {"south", ""}, {"ellps", "GRS80"}}},
+	}
+	if len(steps) != len(expected) {
+		t.Fatal("unexpected steps", steps)
+	}
+	for i := range steps {
+		if steps[i].String() != expected[i].String() {
+			t.Errorf("%q != %q", steps[i], expected[i])
+		}
+	}
+	if s := steps[2].String(); s != "+inv +proj=utm +zone=32 +south +ellps=GRS80" {
+		t.Error("unexpected string", s)
+	}
+
+	if _, err := parsePipeline("+proj=pipeline +step +inv"); err == nil {
+		t.Error("no error for step without proj")
+	}
+}
+
+func TestTransformerMissingGrids(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := transf.MissingGrids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 {
+		t.Error("unexpected missing grids", missing)
+	}
+
+	// WGS 84 3D to WGS 84 + EGM96 height requires a geoid grid
+	transf, err = NewTransformer("epsg:4979", "epsg:4326+5773")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err = transf.MissingGrids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range missing {
+		if g.Available {
+			t.Error("available grid reported as missing", g)
+		}
+		if g.ShortName == "" {
+			t.Error("missing grid without name", g)
+		}
+	}
+}
+
+func TestAreaOfUsePolygon(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := p.AreaOfUsePolygon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ring) != 5 || ring[0] != ring[4] {
+		t.Fatal("unexpected ring", ring)
+	}
+	if ring[0].X != 6 || ring[1].X != 12 || ring[2].Y <= ring[1].Y {
+		t.Error("unexpected ring", ring)
+	}
+
+	// crosses antimeridian (Fiji)
+	p, err = NewEPSG(3460)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err = p.AreaOfUsePolygon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring[1].X <= 180 || ring[1].X <= ring[0].X {
+		t.Error("unexpected ring", ring)
+	}
+
+	p, err = New("+proj=utm +zone=32 +ellps=GRS80 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AreaOfUsePolygon(); err == nil {
+		t.Error("no error for unknown area of use")
+	}
+}
+
+func TestTransformerAvailableOffline(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline, err := transf.AvailableOffline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !offline {
+		t.Error("transformation without grids not available offline")
+	}
+
+	// requires egm96_15.tif, which is only available if installed locally
+	transf, err = NewTransformer("epsg:4979", "epsg:4326+5773")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transf.AvailableOffline(); err != nil {
+		t.Fatal(err)
+	}
+
+	transf = Transformer{}
+	if _, err := transf.AvailableOffline(); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
@@ -0,0 +1,530 @@
+package proj
+
+// #include <proj.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// TransformOptions configure the selection of the coordinate operation of a
+// Transformer.
+type TransformOptions struct {
+	// Authorities restricts the coordinate operations to the given
+	// authorities (e.g. "EPSG" or "IGNF"). Operations of all authorities
+	// are considered if empty.
+	Authorities []string
+
+	// Options are passed as additional options to
+	// proj_create_crs_to_crs_from_pj (e.g. "ACCURACY=1" or
+	// "ALLOW_BALLPARK=NO").
+	Options []string
+
+	// RejectNonFinite checks all coordinates before transforming and
+	// returns an InvalidCoordinateError for the first coordinate with a
+	// NaN or infinite X or Y.
+	RejectNonFinite bool
+
+	// ValidateGeographic checks the coordinates of geographic Src
+	// projections before transforming and returns an
+	// InvalidCoordinateError for the first coordinate with a latitude
+	// outside of [-90, 90] or a longitude outside of [MinLongitude,
+	// MaxLongitude]. Coordinates are only checked by PROJ if not set.
+	ValidateGeographic bool
+
+	// MinLongitude and MaxLongitude define the range of valid longitudes
+	// (in degree) for ValidateGeographic. The range is [-180, 360] if both
+	// are 0.
+	MinLongitude, MaxLongitude float64
+
+	// InputHeight is the expected type of the heights (Z) of the input
+	// coordinates. NewTransformerWithOptions returns an error if the source
+	// CRS uses a different type of height. Not validated for HeightUnknown.
+	InputHeight HeightType
+
+	// PivotCRS forces the use of an intermediate CRS (e.g. "EPSG:4258" for
+	// ETRS89) for transformations between different datums. Only
+	// concatenated operations that transform via this CRS are used, direct
+	// operations are skipped. The preferred of these operations is used for
+	// all coordinates if PivotCRS is set, instead of selecting the operation
+	// for each coordinate.
+	PivotCRS string
+
+	// Method restricts the coordinate operations to operations that use
+	// the operation method with this name (e.g. "NTv2" or "Molodensky"),
+	// either directly or in one of their steps. Case-insensitive. The
+	// preferred coordinate operation is used for all coordinates if Method
+	// is set, like with PivotCRS.
+	Method string
+
+	// AreaOfInterest restricts the coordinate operations to operations
+	// that are valid for the area.
+	AreaOfInterest *Area
+
+	// UsePrimaryGridNamesOnly restricts the coordinate operations to the
+	// grid names of the authority (e.g. EPSG), instead of also considering
+	// the alternative grid names known by PROJ. This makes the selection
+	// independent of other grid files in the search path. The preferred
+	// coordinate operation is used for all coordinates if
+	// UsePrimaryGridNamesOnly is set, like with PivotCRS.
+	UsePrimaryGridNamesOnly bool
+
+	// LonWrap is the central longitude (in degree) of the output
+	// longitudes of geographic Dst projections, like +lon_wrap of PROJ.
+	// Longitudes are wrapped to [LonWrap-180, LonWrap+180], e.g. to
+	// [0, 360] for data centered on the Pacific with a LonWrap of 180.
+	// Longitudes are returned in the range of PROJ (usually [-180, 180])
+	// if LonWrap is 0.
+	LonWrap float64
+
+	// NetworkRetries is the number of times a transformation is retried if
+	// it fails with a network error (see ErrNetwork), e.g. if a grid could
+	// not be downloaded from the CDN. Only used if network access is
+	// enabled. All coordinates are transformed again for each retry.
+	NetworkRetries int
+
+	// NetworkRetryBackoff is the delay before the first retry. The delay is
+	// doubled for each further retry.
+	NetworkRetryBackoff time.Duration
+
+	// RecordLastUsedOperation keeps the coordinate operation that was used
+	// for the last coordinate of each Transform, for LastUsedOperation.
+	// Disabled by default, as it copies the operation for each Transform.
+	RecordLastUsedOperation bool
+
+	// ignoreGridAvailability sorts operations regardless of whether the
+	// required grids are available.
+	ignoreGridAvailability bool
+
+	// pipeline is a proj string of a fixed coordinate operation, used
+	// instead of the operation selection of PROJ.
+	pipeline string
+
+	// invertPipeline applies pipeline in inverse direction.
+	invertPipeline bool
+}
+
+// direction returns the direction in which the transformation of the
+// options transforms from src to dst.
+func (o TransformOptions) direction() C.PJ_DIRECTION {
+	if o.pipeline != "" && o.invertPipeline {
+		return C.PJ_INV
+	}
+	return C.PJ_FWD
+}
+
+// hasTransformSettings returns whether the options set options that are
+// applied while transforming coordinates, besides LonWrap and the selection
+// of the coordinate operation.
+func (o TransformOptions) hasTransformSettings() bool {
+	return o.RejectNonFinite || o.ValidateGeographic || o.MinLongitude != 0 || o.MaxLongitude != 0 ||
+		o.NetworkRetries != 0 || o.NetworkRetryBackoff != 0 || o.RecordLastUsedOperation
+}
+
+// longitudeRange returns the range of valid longitudes (in degree) for
+// ValidateGeographic.
+func (o TransformOptions) longitudeRange() (min, max float64) {
+	if o.MinLongitude == 0 && o.MaxLongitude == 0 {
+		return -180, 360
+	}
+	return o.MinLongitude, o.MaxLongitude
+}
+
+// crsToCRSOptions returns the options for proj_create_crs_to_crs_from_pj.
+func (o TransformOptions) crsToCRSOptions() []string {
+	var opts []string
+	if len(o.Authorities) > 0 {
+		opts = append(opts, "AUTHORITY="+strings.Join(o.Authorities, ","))
+	}
+	return append(opts, o.Options...)
+}
+
+// NewTransformerWithOptions initializes new transformer with src and dst
+// projection like NewTransformer. opts configure the selection of the
+// coordinate operation.
+func NewTransformerWithOptions(initSrc, initDst string, opts TransformOptions) (Transformer, error) {
+	t, err := NewTransformer(initSrc, initDst)
+	if err != nil {
+		return Transformer{}, err
+	}
+	if opts.InputHeight != HeightUnknown {
+		if h := t.Src.HeightType(); h != opts.InputHeight {
+			return Transformer{}, fmt.Errorf("source CRS uses %s heights, not %s heights", h, opts.InputHeight)
+		}
+	}
+	t.opts = opts
+	return t, nil
+}
+
+// Area is a bounding box in degree (longitude and latitude), e.g. an area of
+// interest. Areas can be declared as literals, or created with NewArea to
+// reuse the underlying PJ_AREA for multiple transformers and queries.
+type Area struct {
+	West, South, East, North float64
+
+	pj *C.PJ_AREA // see NewArea
+}
+
+// NewArea creates a new area. The fields of the area must not be modified
+// after NewArea.
+func NewArea(west, south, east, north float64) *Area {
+	a := &Area{West: west, South: south, East: east, North: north}
+	a.pj = C.proj_area_create()
+	C.proj_area_set_bbox(a.pj, C.double(west), C.double(south), C.double(east), C.double(north))
+	runtime.SetFinalizer(a, (*Area).Free)
+	return a
+}
+
+// Free deallocates the area immediately. Areas created by NewArea will be
+// deallocated on garbage collection otherwise. Free is a no-op for areas
+// declared as literals.
+func (a *Area) Free() {
+	if a.pj != nil {
+		C.proj_area_destroy(a.pj)
+		a.pj = nil
+	}
+}
+
+// pjArea returns the PJ_AREA of the area and a function to release it after
+// use. Returns nil for nil areas.
+func (a *Area) pjArea() (*C.PJ_AREA, func()) {
+	if a == nil {
+		return nil, func() {}
+	}
+	if a.pj != nil {
+		return a.pj, func() { runtime.KeepAlive(a) }
+	}
+	area := C.proj_area_create()
+	C.proj_area_set_bbox(area, C.double(a.West), C.double(a.South), C.double(a.East), C.double(a.North))
+	return area, func() { C.proj_area_destroy(area) }
+}
+
+// bbox returns the bounding box of the area, e.g. for cache keys.
+func (a Area) bbox() [4]float64 {
+	return [4]float64{a.West, a.South, a.East, a.North}
+}
+
+// HeightType is the type of heights (Z values) of a CRS.
+type HeightType int
+
+const (
+	HeightUnknown HeightType = iota
+	// HeightNone is used by 2D CRS. Z values are not transformed.
+	HeightNone
+	// HeightEllipsoidal is the height above the ellipsoid, as used by
+	// geographic 3D and geocentric CRS.
+	HeightEllipsoidal
+	// HeightOrthometric is the gravity-related height (e.g. above a geoid),
+	// as used by vertical and compound CRS.
+	HeightOrthometric
+)
+
+func (h HeightType) String() string {
+	switch h {
+	case HeightNone:
+		return "no"
+	case HeightEllipsoidal:
+		return "ellipsoidal"
+	case HeightOrthometric:
+		return "orthometric"
+	default:
+		return "unknown"
+	}
+}
+
+// HeightType returns the type of heights used by the CRS.
+func (p *Proj) HeightType() HeightType {
+	switch C.proj_get_type(p.p) {
+	case C.PJ_TYPE_GEOGRAPHIC_3D_CRS, C.PJ_TYPE_GEOCENTRIC_CRS:
+		return HeightEllipsoidal
+	case C.PJ_TYPE_VERTICAL_CRS, C.PJ_TYPE_COMPOUND_CRS:
+		return HeightOrthometric
+	case C.PJ_TYPE_GEOGRAPHIC_2D_CRS, C.PJ_TYPE_PROJECTED_CRS:
+		if n, err := p.AxisCount(); err == nil && n == 3 {
+			// e.g. projected 3D CRS based on geographic 3D CRS
+			return HeightEllipsoidal
+		}
+		return HeightNone
+	case C.PJ_TYPE_BOUND_CRS:
+		base, err := p.Unbound()
+		if err != nil {
+			return HeightUnknown
+		}
+		defer base.Free()
+		return base.HeightType()
+	default:
+		return HeightUnknown
+	}
+}
+
+// NewTransformerOpts initializes a new transformer with src and dst
+// projection by the numeric EPSG code. options are passed to
+// proj_create_crs_to_crs (e.g. "ACCURACY=1" or "ALLOW_BALLPARK=NO").
+// Returns an error if PROJ does not accept the options.
+func NewTransformerOpts(srcEPSG, dstEPSG int, options []string) (Transformer, error) {
+	t, err := NewEPSGTransformer(srcEPSG, dstEPSG)
+	if err != nil {
+		return Transformer{}, err
+	}
+	t.opts = TransformOptions{Options: options}
+
+	// Check options.
+	tr, err := createTransformation(t.Src.context(), t.Src, t.Dst, t.opts)
+	if err != nil {
+		return Transformer{}, err
+	}
+	C.proj_destroy(tr)
+	return t, nil
+}
+
+// NewStrictTransformer initializes a new transformer with src and dst
+// projection by the numeric EPSG code, that only uses exact coordinate
+// operations. Returns an error if the preferred operation is a ballpark
+// transformation (e.g. without datum shift), or if it requires grids that are
+// not available. area restricts the operations to an area of interest and
+// can be nil.
+func NewStrictTransformer(srcEPSG, dstEPSG int, area *Area) (Transformer, error) {
+	t, err := NewEPSGTransformer(srcEPSG, dstEPSG)
+	if err != nil {
+		return Transformer{}, err
+	}
+	t.opts = TransformOptions{
+		Options:        []string{"ALLOW_BALLPARK=NO"},
+		AreaOfInterest: area,
+	}
+
+	opts := t.opts
+	opts.ignoreGridAvailability = true
+	op, err := preferredOperation(t.Src.context(), t.Src, t.Dst, opts)
+	if err != nil {
+		return Transformer{}, err
+	}
+	desc := describeOperation(t.Src.context(), op)
+	C.proj_destroy(op)
+	if desc.Ballpark {
+		return Transformer{}, fmt.Errorf("only ballpark transformation from EPSG:%d to EPSG:%d available", srcEPSG, dstEPSG)
+	}
+	for _, g := range desc.Grids {
+		if !g.Available {
+			return Transformer{}, fmt.Errorf("transformation %q requires missing grid %s", desc.Name, g.ShortName)
+		}
+	}
+
+	tr, err := createTransformation(t.Src.context(), t.Src, t.Dst, t.opts)
+	if err != nil {
+		return Transformer{}, err
+	}
+	C.proj_destroy(tr)
+	return t, nil
+}
+
+// createTransformation creates the transformation from src to dst.
+// The returned PJ needs to be destroyed by the caller.
+func createTransformation(ctx *C.PJ_CONTEXT, src, dst *Proj, opts TransformOptions) (*C.PJ, error) {
+	if opts.pipeline != "" {
+		c := C.CString(opts.pipeline)
+		defer C.free(unsafe.Pointer(c))
+		tr := C.proj_create(ctx, c)
+		if tr == nil {
+			return nil, ctxError(ctx)
+		}
+		return tr, nil
+	}
+	if opts.fixedOperation() {
+		return preferredOperation(ctx, src, dst, opts)
+	}
+
+	cOpts := newCStringList(opts.crsToCRSOptions())
+	defer cOpts.free()
+
+	area, release := opts.AreaOfInterest.pjArea()
+	defer release()
+
+	tr := C.proj_create_crs_to_crs_from_pj(ctx, src.p, dst.p, area, cOpts.ptr())
+	if tr == nil {
+		return nil, ctxError(ctx)
+	}
+	return tr, nil
+}
+
+// fixedOperation returns whether the options require the selection of a
+// single operation with proj_create_operations, as these options are not
+// supported by proj_create_crs_to_crs.
+func (o TransformOptions) fixedOperation() bool {
+	return o.PivotCRS != "" || o.Method != "" || o.UsePrimaryGridNamesOnly
+}
+
+// preferredOperation returns the preferred coordinate operation from src to
+// dst. The returned operation needs to be destroyed by the caller.
+func preferredOperation(ctx *C.PJ_CONTEXT, src, dst *Proj, opts TransformOptions) (*C.PJ, error) {
+	ops, err := createOperations(ctx, src, dst, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer C.proj_list_destroy(ops)
+
+	n := int(C.proj_list_get_count(ops))
+	for i := 0; i < n; i++ {
+		op := C.proj_list_get(ctx, ops, C.int(i))
+		if op == nil {
+			return nil, ctxError(ctx)
+		}
+		if (opts.Method == "" || usesMethod(ctx, op, opts.Method)) &&
+			(opts.PivotCRS == "" || usesPivot(ctx, op, opts.PivotCRS)) {
+			return op, nil
+		}
+		C.proj_destroy(op)
+	}
+	if opts.Method != "" {
+		return nil, fmt.Errorf("no coordinate operation with method %q found", opts.Method)
+	}
+	if opts.PivotCRS != "" {
+		return nil, fmt.Errorf("no coordinate operation via %q found", opts.PivotCRS)
+	}
+	return nil, errors.New("no coordinate operation found")
+}
+
+// usesMethod returns whether the operation op or one of its steps uses the
+// operation method with the name method.
+func usesMethod(ctx *C.PJ_CONTEXT, op *C.PJ, method string) bool {
+	if C.proj_get_type(op) == C.PJ_TYPE_CONCATENATED_OPERATION {
+		n := int(C.proj_concatoperation_get_step_count(ctx, op))
+		for i := 0; i < n; i++ {
+			step := C.proj_concatoperation_get_step(ctx, op, C.int(i))
+			if step == nil {
+				continue
+			}
+			found := usesMethod(ctx, step, method)
+			C.proj_destroy(step)
+			if found {
+				return true
+			}
+		}
+		return false
+	}
+	var name *C.char
+	if C.proj_coordoperation_get_method_info(ctx, op, &name, nil, nil) == 0 || name == nil {
+		return false
+	}
+	// Inverse operations use the method of the forward operation.
+	methodName := strings.TrimPrefix(C.GoString(name), "Inverse of ")
+	return strings.EqualFold(methodName, method)
+}
+
+// usesPivot returns whether the concatenated operation op transforms via the
+// intermediate CRS pivot (e.g. "EPSG:4258"). PROJ only uses the allowed
+// intermediate CRS to limit the pivots, direct operations are still
+// returned.
+func usesPivot(ctx *C.PJ_CONTEXT, op *C.PJ, pivot string) bool {
+	if C.proj_get_type(op) != C.PJ_TYPE_CONCATENATED_OPERATION {
+		return false
+	}
+	parts := strings.SplitN(pivot, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	n := int(C.proj_concatoperation_get_step_count(ctx, op))
+	// The target of the last step is the target CRS of the operation.
+	for i := 0; i < n-1; i++ {
+		step := C.proj_concatoperation_get_step(ctx, op, C.int(i))
+		if step == nil {
+			continue
+		}
+		crs := C.proj_get_target_crs(ctx, step)
+		C.proj_destroy(step)
+		if crs == nil {
+			continue
+		}
+		auth, code := objectID(crs)
+		C.proj_destroy(crs)
+		if strings.EqualFold(auth, parts[0]) && code == parts[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// createOperations returns all coordinate operations from src to dst,
+// sorted by preference. The returned list needs to be destroyed by the
+// caller.
+func createOperations(ctx *C.PJ_CONTEXT, src, dst *Proj, opts TransformOptions) (*C.PJ_OBJ_LIST, error) {
+	var authority *C.char
+	if len(opts.Authorities) > 0 {
+		authority = C.CString(strings.Join(opts.Authorities, ","))
+		defer C.free(unsafe.Pointer(authority))
+	}
+	factory := C.proj_create_operation_factory_context(ctx, authority)
+	if factory == nil {
+		return nil, ctxError(ctx)
+	}
+	defer C.proj_operation_factory_context_destroy(factory)
+
+	// Same criteria as proj_create_crs_to_crs.
+	C.proj_operation_factory_context_set_spatial_criterion(ctx, factory, C.PROJ_SPATIAL_CRITERION_PARTIAL_INTERSECTION)
+	if a := opts.AreaOfInterest; a != nil {
+		C.proj_operation_factory_context_set_area_of_interest(ctx, factory, C.double(a.West), C.double(a.South), C.double(a.East), C.double(a.North))
+	}
+	if opts.ignoreGridAvailability {
+		C.proj_operation_factory_context_set_grid_availability_use(ctx, factory, C.PROJ_GRID_AVAILABILITY_IGNORED)
+	} else {
+		C.proj_operation_factory_context_set_grid_availability_use(ctx, factory, C.PROJ_GRID_AVAILABILITY_USED_FOR_SORTING)
+	}
+
+	if opts.UsePrimaryGridNamesOnly {
+		C.proj_operation_factory_context_set_use_proj_alternative_grid_names(ctx, factory, 0)
+	}
+
+	if opts.PivotCRS != "" {
+		parts := strings.SplitN(opts.PivotCRS, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid PivotCRS %q, expected AUTHORITY:CODE", opts.PivotCRS)
+		}
+		pivot := newCStringList(parts)
+		defer pivot.free()
+		C.proj_operation_factory_context_set_allow_use_intermediate_crs(ctx, factory, C.PROJ_INTERMEDIATE_CRS_USE_ALWAYS)
+		C.proj_operation_factory_context_set_allowed_intermediate_crs(ctx, factory, pivot.ptr())
+	}
+
+	ops := C.proj_create_operations(ctx, src.p, dst.p, factory)
+	if ops == nil {
+		return nil, ctxError(ctx)
+	}
+	return ops, nil
+}
+
+// cStringList is a NULL terminated list of C strings.
+type cStringList []*C.char
+
+func newCStringList(strs []string) cStringList {
+	if len(strs) == 0 {
+		return nil
+	}
+	l := make(cStringList, 0, len(strs)+1)
+	for _, s := range strs {
+		l = append(l, C.CString(s))
+	}
+	return append(l, nil)
+}
+
+// ptr returns the pointer to the first element, or nil for empty lists.
+// The list is allocated in Go memory and must not be retained by C.
+func (l cStringList) ptr() **C.char {
+	if len(l) == 0 {
+		return nil
+	}
+	return &l[0]
+}
+
+func (l cStringList) free() {
+	for _, s := range l {
+		if s != nil {
+			C.free(unsafe.Pointer(s))
+		}
+	}
+}
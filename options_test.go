@@ -0,0 +1,452 @@
+package proj
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTransformerWithOptions(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{Authorities: []string{"EPSG"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
+	}
+	desc, err := transf.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(desc.Name, "UTM zone 32N") {
+		t.Error("unexpected operation", desc.Name)
+	}
+
+	transf, err = NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{Authorities: []string{"UNKNOWN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transf.Describe(); err == nil {
+		t.Error("no error for unknown authority")
+	}
+}
+
+func TestNewTransformerOpts(t *testing.T) {
+	transf, err := NewTransformerOpts(4326, 25832, []string{"ALLOW_BALLPARK=NO", "ACCURACY=10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
+	}
+
+	if _, err := NewTransformerOpts(4326, 25832, []string{"UNKNOWN_OPTION=YES"}); err == nil {
+		t.Error("no error for unknown option")
+	}
+}
+
+func TestNewStrictTransformer(t *testing.T) {
+	transf, err := NewStrictTransformer(4326, 25832, &Area{West: 5, South: 47, East: 15, North: 55})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+
+	// no NAD27 transformations in Germany
+	_, err = NewStrictTransformer(4326, 4267, &Area{West: 5, South: 47, East: 15, North: 55})
+	if err == nil || !strings.Contains(err.Error(), "ballpark") {
+		t.Error("no/unexpected error for ballpark transformation", err)
+	}
+}
+
+func TestNewArea(t *testing.T) {
+	area := NewArea(5, 47, 15, 55)
+	defer area.Free()
+
+	for _, dst := range []int{25832, 25833} {
+		if _, err := NewStrictTransformer(4326, dst, area); err != nil {
+			t.Fatal(dst, err)
+		}
+	}
+
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{AreaOfInterest: area})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+
+	area.Free()
+	if area.pj != nil {
+		t.Error("area not freed")
+	}
+	// still usable as a plain bounding box
+	pts = []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransformArea(t *testing.T) {
+	// DHDN to WGS 84
+	transf, err := NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{RecordLastUsedOperation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	transf.OnTransform = func(n int, d time.Duration) { calls++ }
+
+	pts := []Coord{XY(5896773.991, 3443269.238)}
+	if err := transf.TransformArea(pts, 7.5, 52.5, 9, 54); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.0001) {
+		t.Error(pts)
+	}
+	if hasLastUsedOperation() {
+		op, err := transf.LastUsedOperation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op.AreaOfUse == nil || op.AreaOfUse.West > 7.5 || op.AreaOfUse.East < 9 {
+			t.Error("unexpected area of use of operation", op.AreaOfUse)
+		}
+	}
+	if calls != 1 {
+		t.Error("OnTransform not called")
+	}
+	if transf.opts.AreaOfInterest != nil {
+		t.Error("area of interest of transformer modified")
+	}
+}
+
+func TestRejectNonFinite(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:25832", "epsg:4326", TransformOptions{RejectNonFinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		pts       []Coord
+		index     int
+		component string
+	}{
+		{[]Coord{XY(443220.719, 5894856.508), XY(math.NaN(), 5894856.508)}, 1, "X"},
+		{[]Coord{XY(443220.719, math.Inf(1))}, 0, "Y"},
+		{[]Coord{XY(443220.719, 5894856.508), XY(443220.719, 5894856.508), XY(math.Inf(-1), math.NaN())}, 2, "X"},
+	}
+	for _, tt := range tests {
+		err := transf.Transform(tt.pts)
+		invalid, ok := err.(*InvalidCoordinateError)
+		if !ok {
+			t.Errorf("unexpected error %v for %v", err, tt.pts)
+			continue
+		}
+		if invalid.Index != tt.index || invalid.Component != tt.component {
+			t.Errorf("unexpected error %#v for %v", invalid, tt.pts)
+		}
+		if !errors.Is(err, ErrInvalidCoordinate) {
+			t.Error("unexpected error", err)
+		}
+	}
+
+	pts := []Coord{XY(443220.719, 5894856.508)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.0001) {
+		t.Error(pts)
+	}
+}
+
+func TestPivotCRS(t *testing.T) {
+	// DHDN to WGS 84 via ETRS89
+	transf, err := NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{PivotCRS: "EPSG:4258", RecordLastUsedOperation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(5896773.991, 3443269.238)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.001) {
+		t.Error(pts)
+	}
+	if hasLastUsedOperation() {
+		desc, err := transf.LastUsedOperation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(desc.Name, "DHDN to ETRS89") {
+			t.Error("operation not via ETRS89:", desc.Name)
+		}
+	}
+
+	transf, err = NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{PivotCRS: "4258"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.Transform(pts); err == nil {
+		t.Error("no error for invalid PivotCRS")
+	}
+}
+
+func TestMethod(t *testing.T) {
+	// DHDN to WGS 84 with 7 parameter transformation
+	transf, err := NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{Method: "coordinate frame rotation (geog2D domain)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(5896773.991, 3443269.238)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	// accuracy of the transformation is about 1m
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.0001) {
+		t.Error(pts)
+	}
+
+	transf, err = NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{Method: "unknown method"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(5896773.991, 3443269.238)}
+	if err := transf.Transform(pts); err == nil || !strings.Contains(err.Error(), "unknown method") {
+		t.Error("no/unexpected error for unknown method", err)
+	}
+}
+
+func TestUsePrimaryGridNamesOnly(t *testing.T) {
+	// DHDN to WGS 84
+	transf, err := NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{UsePrimaryGridNamesOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !transf.opts.fixedOperation() {
+		t.Error("UsePrimaryGridNamesOnly requires a fixed operation")
+	}
+	pts := []Coord{XY(5896773.991, 3443269.238)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.001) {
+		t.Error(pts)
+	}
+}
+
+func TestHeightType(t *testing.T) {
+	var tests = []struct {
+		init   string
+		height HeightType
+	}{
+		{"epsg:4326", HeightNone},
+		{"epsg:25832", HeightNone},
+		{"epsg:4979", HeightEllipsoidal},
+		{"epsg:4978", HeightEllipsoidal},
+		{"epsg:5773", HeightOrthometric},
+		{"epsg:4326+5773", HeightOrthometric},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.init)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if h := p.HeightType(); h != tt.height {
+			t.Errorf("%s != %s for %s", h, tt.height, tt.init)
+		}
+	}
+}
+
+func TestInputHeight(t *testing.T) {
+	// WGS 84 3D to WGS 84 + EGM96 height
+	transf, err := NewTransformerWithOptions("epsg:4979", "epsg:4326+5773", TransformOptions{InputHeight: HeightEllipsoidal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := transf.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range desc.Grids {
+		if !g.Available {
+			t.Skip("geoid grid not available:", g.ShortName)
+		}
+	}
+	if len(desc.Grids) == 0 {
+		t.Skip("no geoid grid used:", desc.Name)
+	}
+
+	// ellipsoidal height to orthometric height, geoid is ~40m above the ellipsoid
+	pts := []Coord{{X: 53.2, Y: 8.15, Z: 100, T: math.MaxFloat64}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if pts[0].Z < 50 || pts[0].Z > 65 {
+		t.Error("unexpected orthometric height", pts[0])
+	}
+
+	if _, err := NewTransformerWithOptions("epsg:4979", "epsg:4326+5773", TransformOptions{InputHeight: HeightOrthometric}); err == nil {
+		t.Error("no error for orthometric input height with ellipsoidal source CRS")
+	}
+	if _, err := NewTransformerWithOptions("epsg:4326", "epsg:4979", TransformOptions{InputHeight: HeightEllipsoidal}); err == nil {
+		t.Error("no error for ellipsoidal input height with 2D source CRS")
+	}
+}
+
+func TestCStringList(t *testing.T) {
+	l := newCStringList(nil)
+	if l.ptr() != nil {
+		t.Error("expected nil pointer for empty list")
+	}
+	l.free()
+
+	l = newCStringList([]string{"A=1", "B=2"})
+	defer l.free()
+	if len(l) != 3 || l[2] != nil {
+		t.Fatal("list not NULL terminated", l)
+	}
+}
+
+func TestTransformNetworkRetries(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{
+		NetworkRetries:      3,
+		NetworkRetryBackoff: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+
+	// coordinate errors are not retried
+	inv := transf.Inverse()
+	start := time.Now()
+	pts = []Coord{XY(443220.719, 5894856.508), XY(443220.719, 1e10)}
+	err = inv.Transform(pts)
+	if _, ok := err.(*TransformError); !ok || errors.Is(err, ErrNetwork) {
+		t.Fatal("unexpected error", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("coordinate error was retried")
+	}
+}
+
+func TestLonWrap(t *testing.T) {
+	merc := "+proj=merc +lon_0=180 +datum=WGS84 +type=crs"
+	transf, err := NewTransformerWithOptions(merc, "+proj=longlat +datum=WGS84 +type=crs", TransformOptions{LonWrap: 180})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// west and east of the antimeridian
+	src := []Coord{XY(-100000, 0), XY(100000, 0)}
+	pts := append([]Coord(nil), src...)
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !(pts[0].X > 179 && pts[0].X < 180) || !(pts[1].X > 180 && pts[1].X < 181) {
+		t.Error("longitudes not wrapped", pts)
+	}
+
+	// same for Func and TransformWithAccuracy
+	f, err := transf.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := f(src[1]); err != nil || !(c.X > 180 && c.X < 181) {
+		t.Error("longitude not wrapped by Func", c, err)
+	}
+	pts = append([]Coord(nil), src...)
+	if _, err := transf.TransformWithAccuracy(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !(pts[1].X > 180 && pts[1].X < 181) {
+		t.Error("longitude not wrapped by TransformWithAccuracy", pts)
+	}
+
+	transf, err = NewTransformer(merc, "+proj=longlat +datum=WGS84 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = append([]Coord(nil), src...)
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !(pts[1].X > -180 && pts[1].X < -179) {
+		t.Error("unexpected longitude", pts)
+	}
+}
+
+func TestRetryOnNetworkError(t *testing.T) {
+	networkErr := &TransformError{Index: -1, Err: &projError{msg: "network error", errno: errnoNetwork}}
+	if !errors.Is(networkErr, ErrNetwork) || !errors.Is(networkErr, ErrProj) {
+		t.Fatal("network error not detected", networkErr)
+	}
+
+	// trans modifies the coordinates and fails twice
+	var calls int
+	trans := func(pts []Coord) error {
+		calls++
+		if pts[0] != XY(1, 2) {
+			t.Error("coordinates not restored", pts[0])
+		}
+		pts[0] = XY(3, 4)
+		if calls <= 2 {
+			return networkErr
+		}
+		return nil
+	}
+	pts := []Coord{XY(1, 2)}
+	if err := retryOnNetworkError(pts, 3, time.Millisecond, trans); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || pts[0] != XY(3, 4) {
+		t.Error("unexpected result", calls, pts)
+	}
+
+	// retries exceeded
+	calls = 0
+	pts = []Coord{XY(1, 2)}
+	if err := retryOnNetworkError(pts, 1, time.Millisecond, trans); !errors.Is(err, ErrNetwork) {
+		t.Error("unexpected error", err)
+	}
+	if calls != 2 {
+		t.Error("unexpected number of calls", calls)
+	}
+
+	// other errors are not retried
+	calls = 0
+	otherErr := &TransformError{Index: 0, Err: &projError{msg: "invalid coordinate"}}
+	err := retryOnNetworkError(pts, 3, time.Millisecond, func(pts []Coord) error {
+		calls++
+		return otherErr
+	})
+	if err != otherErr || calls != 1 {
+		t.Error("unexpected retry", calls, err)
+	}
+}
@@ -0,0 +1,85 @@
+package proj
+
+// #include <proj.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// Direction is the direction of a coordinate operation.
+type Direction int
+
+const (
+	Forward Direction = iota
+	Inverse
+)
+
+// Pipeline is a coordinate operation that is defined by a proj string (e.g.
+// "+proj=pipeline +step +proj=unitconvert +xy_in=deg +xy_out=rad +step
+// +proj=utm +zone=32 +ellps=GRS80"), instead of a source and destination
+// CRS.
+type Pipeline struct {
+	pj  *C.PJ
+	ctx *C.PJ_CONTEXT
+}
+
+// NewPipeline initializes a new coordinate operation from a proj string.
+// Returns an error if def is not a coordinate operation (e.g. a CRS).
+func NewPipeline(def string) (*Pipeline, error) {
+	ctx := newContext()
+	c := C.CString(def)
+	defer C.free(unsafe.Pointer(c))
+	pj := C.proj_create(ctx, c)
+	if pj == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	if C.proj_is_crs(pj) != 0 {
+		C.proj_destroy(pj)
+		destroyContext(ctx)
+		return nil, fmt.Errorf("%q is a CRS, not a coordinate operation", def)
+	}
+	p := &Pipeline{pj: pj, ctx: ctx}
+	runtime.SetFinalizer(p, (*Pipeline).Free)
+	return p, nil
+}
+
+// Transform coordinates in the direction dir. Transforms coordinates
+// in-place.
+func (p *Pipeline) Transform(pts []Coord, dir Direction) error {
+	if p == nil || p.pj == nil {
+		return errors.New("missing/invalid pipeline")
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+	pjDir := C.PJ_DIRECTION(C.PJ_FWD)
+	switch dir {
+	case Forward:
+	case Inverse:
+		pjDir = C.PJ_INV
+	default:
+		return fmt.Errorf("invalid direction %d", dir)
+	}
+	err := transArray(p.ctx, p.pj, pjDir, pts)
+	runtime.KeepAlive(p)
+	return err
+}
+
+// Free frees the pipeline. The pipeline must not be used afterwards.
+func (p *Pipeline) Free() {
+	if p.pj != nil {
+		C.proj_destroy(p.pj)
+		p.pj = nil
+	}
+	if p.ctx != nil {
+		destroyContext(p.ctx)
+		p.ctx = nil
+	}
+}
@@ -0,0 +1,37 @@
+package proj
+
+import (
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	p, err := NewPipeline("+proj=pipeline +step +proj=axisswap +order=2,1 +step +proj=unitconvert +xy_in=deg +xy_out=rad +step +proj=utm +zone=32 +ellps=GRS80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+
+	pts := []Coord{XY(53.2, 8.15)}
+	if err := p.Transform(pts, Forward); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+	if err := p.Transform(pts, Inverse); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 1e-8) {
+		t.Error(pts)
+	}
+	if err := p.Transform(pts, Direction(2)); err == nil {
+		t.Error("no error for invalid direction")
+	}
+
+	if _, err := NewPipeline("epsg:4326"); err == nil {
+		t.Error("no error for CRS")
+	}
+	if _, err := NewPipeline("+proj=unknown"); err == nil {
+		t.Error("no error for invalid pipeline")
+	}
+}
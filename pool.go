@@ -0,0 +1,97 @@
+package proj
+
+import (
+	"errors"
+	"sync"
+)
+
+// TransformerPool lends out transformers for concurrent use. Transformers
+// and their projections are not safe for concurrent use. Each transformer of
+// the pool uses clones of the projections of the template transformer, so
+// that each goroutine can use its own transformer, without creating the
+// projections for each use.
+type TransformerPool struct {
+	tmpl Transformer
+	pool sync.Pool
+}
+
+// NewTransformerPool returns a new pool for transformers like t. t itself is
+// not lent out by the pool and can still be used by the caller.
+func NewTransformerPool(t Transformer) (*TransformerPool, error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	p := &TransformerPool{tmpl: t}
+	// Check that projections can be cloned.
+	c, err := p.clone()
+	if err != nil {
+		return nil, err
+	}
+	p.pool.Put(c)
+	return p, nil
+}
+
+// Get returns a transformer from the pool and a function to return the
+// transformer to the pool after use. The transformer must not be used after
+// it was returned. Returns an error if the projections can not be cloned,
+// which only happens if PROJ runs out of memory.
+func (p *TransformerPool) Get() (*Transformer, func(), error) {
+	t, ok := p.pool.Get().(*Transformer)
+	if !ok {
+		var err error
+		t, err = p.clone()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return t, func() {
+		// Reset all fields that the caller might have changed.
+		*t = Transformer{
+			Src:           t.Src,
+			Dst:           t.Dst,
+			ClampLatitude: p.tmpl.ClampLatitude,
+			OnTransform:   p.tmpl.OnTransform,
+			opts:          p.tmpl.opts,
+		}
+		p.pool.Put(t)
+	}, nil
+}
+
+func (p *TransformerPool) clone() (*Transformer, error) {
+	src, err := p.tmpl.Src.Clone()
+	if err != nil {
+		return nil, err
+	}
+	dst, err := p.tmpl.Dst.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &Transformer{
+		Src:           src,
+		Dst:           dst,
+		ClampLatitude: p.tmpl.ClampLatitude,
+		OnTransform:   p.tmpl.OnTransform,
+		opts:          p.tmpl.opts,
+	}, nil
+}
+
+// scratchCoords pools the temporary coordinate buffers of the transformation
+// paths that need a copy of the input (e.g. TransformAny or Transform2D),
+// so that long-running services do not allocate a new buffer for each call.
+var scratchCoords sync.Pool
+
+// getScratch returns a pooled buffer of n coordinates with undefined
+// content. The buffer needs to be returned with putScratch after use and
+// must not be used afterwards.
+func getScratch(n int) *[]Coord {
+	if b, ok := scratchCoords.Get().(*[]Coord); ok && cap(*b) >= n {
+		*b = (*b)[:n]
+		return b
+	}
+	b := make([]Coord, n)
+	return &b
+}
+
+func putScratch(b *[]Coord) {
+	scratchCoords.Put(b)
+}
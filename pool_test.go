@@ -0,0 +1,97 @@
+package proj
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTransformerPool(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewTransformerPool(transf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				tr, put, err := pool.Get()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if tr.Src == transf.Src || tr.Dst == transf.Dst {
+					t.Error("transformer shares projections with template")
+				}
+				pts := []Coord{XY(53.2, 8.15)}
+				if err := tr.Transform(pts); err != nil {
+					t.Error(err)
+				}
+				if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+					t.Error(pts)
+				}
+				tr.ClampLatitude = 10
+				put()
+			}
+		}()
+	}
+	wg.Wait()
+
+	tr, put, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer put()
+	if tr.ClampLatitude != 0 {
+		t.Error("transformer not reset", tr.ClampLatitude)
+	}
+
+	if _, err := NewTransformerPool(Transformer{}); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
+
+func TestTransformerPoolCloneError(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewTransformerPool(transf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// take the transformer that was cloned by NewTransformerPool
+	if _, _, err := pool.Get(); err != nil {
+		t.Fatal(err)
+	}
+	// projections of the template can not be cloned after Free
+	transf.Src.Free()
+	tr, put, err := pool.Get()
+	if err == nil || tr != nil || put != nil {
+		t.Error("no error for failed clone", tr, err)
+	}
+}
+
+func TestScratch(t *testing.T) {
+	b := getScratch(10)
+	if len(*b) != 10 {
+		t.Fatal("unexpected length", len(*b))
+	}
+	putScratch(b)
+	b = getScratch(5)
+	if len(*b) != 5 {
+		t.Error("unexpected length", len(*b))
+	}
+	putScratch(b)
+	b = getScratch(20)
+	if len(*b) != 20 {
+		t.Error("unexpected length", len(*b))
+	}
+	putScratch(b)
+}
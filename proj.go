@@ -51,17 +51,29 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
 // Proj represents a single coordinate reference system.
+//
+// Each Proj owns its PJ and its PJ_CONTEXT. Contexts are never shared
+// between Proj (see Clone), so they can be freed in any order, either by
+// Free or by the finalizer. Proj created by New start without a context of
+// their own, see context.
 type Proj struct {
 	p          *C.PJ
 	ctx        *C.PJ_CONTEXT
 	normalized bool
+	key        *string   // see cacheKey
+	axes       *axisInfo // see geographicAxes
 }
 
 // NewEPSG initializes a new projection by the numeric EPSG code.
@@ -70,23 +82,224 @@ func NewEPSG(epsgCode int) (*Proj, error) {
 }
 
 // New initializes new projection with a proj init string (e.g. "epsg:4326", or "+proj=longlat +datum=WGS84 +no_defs").
+//
+// The projection is created within a shared context, so that the PROJ
+// database is only opened once for many projections. A dedicated context is
+// created on first use of a method that requires one (e.g. Transform).
 func New(init string) (*Proj, error) {
-	ctx := C.proj_context_create()
-	C.proj_log_level(ctx, C.PJ_LOG_NONE)
-
 	c := C.CString(init)
 	defer C.free(unsafe.Pointer(c))
-	proj := C.proj_create(ctx, c)
+
+	lookup.Lock()
+	defer lookup.Unlock()
+	proj := C.proj_create(lookupContext(), c)
 	if proj == nil {
-		errno := C.proj_context_errno(ctx)
-		return nil, errors.New(C.GoString(C.proj_context_errno_string(ctx, errno)))
+		return nil, ctxError(lookup.ctx)
 	}
 
-	p := &Proj{p: proj, ctx: ctx}
-	runtime.SetFinalizer(p, free)
+	return newProj(nil, proj), nil
+}
+
+// lookup is the shared context of all Proj created by New that have no
+// dedicated context yet.
+var lookup struct {
+	sync.Mutex
+	ctx *C.PJ_CONTEXT
+}
+
+// lookupContext returns the shared lookup context. lookup needs to be locked
+// by the caller.
+func lookupContext() *C.PJ_CONTEXT {
+	if lookup.ctx == nil {
+		lookup.ctx = newContext()
+	}
+	return lookup.ctx
+}
+
+// metadataContext returns the context for metadata-only operations, without
+// creating a dedicated context: either the context of the projection, or the
+// shared lookup context. lookup is locked in the latter case, until unlock is
+// called.
+func (p *Proj) metadataContext() (ctx *C.PJ_CONTEXT, unlock func()) {
+	if p.ctx != nil {
+		return p.ctx, func() {}
+	}
+	lookup.Lock()
+	return lookupContext(), lookup.Unlock
+}
+
+// context returns the context of the projection. It creates a dedicated
+// context and assigns it to the PJ, if the projection still uses the shared
+// lookup context.
+func (p *Proj) context() *C.PJ_CONTEXT {
+	if p.ctx == nil {
+		p.ctx = newContext()
+		C.proj_assign_context(p.p, p.ctx)
+	}
+	return p.ctx
+}
+
+// NewURN initializes a new projection by an OGC URN (e.g.
+// "urn:ogc:def:crs:EPSG::4326" or "urn:ogc:def:crs:OGC:1.3:CRS84").
+//
+// The axis order is defined by the authority, as with NewEPSG. Note that
+// PROJ uses the EPSG axis order for both "urn:ogc:def:crs:EPSG::4326" and
+// "epsg:4326" (lat/lon), while "urn:ogc:def:crs:OGC:1.3:CRS84" is WGS 84
+// in lon/lat order.
+func NewURN(urn string) (*Proj, error) {
+	const prefix = "urn:ogc:def:crs:"
+	if len(urn) <= len(prefix) || !strings.EqualFold(urn[:len(prefix)], prefix) {
+		return nil, fmt.Errorf("invalid OGC CRS URN %q, expected %s<authority>:<version>:<code>", urn, prefix)
+	}
+	p, err := New(urn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create projection for %q: %w", urn, err)
+	}
 	return p, nil
 }
 
+// NewFromFile initializes a new projection from a file with a CRS
+// definition, e.g. a .prj or .wkt sidecar file. The file can contain WKT
+// (including ESRI WKT), PROJJSON or a proj string.
+func NewFromFile(path string) (*Proj, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def := strings.TrimSpace(strings.TrimPrefix(string(b), "\ufeff"))
+	switch {
+	case strings.HasPrefix(def, "{"): // PROJJSON
+	case strings.HasPrefix(def, "+") || strings.HasPrefix(def, "proj="): // proj string
+	case wktPrefix.MatchString(def):
+	default:
+		return nil, fmt.Errorf("unrecognized CRS definition in %s, expected WKT, PROJJSON or proj string", path)
+	}
+	p, err := New(def)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create projection from %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// wktPrefix matches the first keyword of a WKT definition, e.g. "PROJCS[" or
+// "GEOGCRS[".
+var wktPrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*\s*[\[(]`)
+
+// NewWithEpoch initializes a new projection with a proj init string (see
+// New) and coordinate metadata with the coordinate epoch (as decimal year,
+// e.g. 2020.5). Use this for dynamic CRS (e.g. ITRF2014), so that
+// transformations take the epoch of the coordinates into account.
+// Returns an error for PROJ versions before 9.2.
+func NewWithEpoch(init string, epoch float64) (*Proj, error) {
+	if !hasCoordinateMetadata() {
+		return nil, unsupportedError("NewWithEpoch", "9.2")
+	}
+	p, err := New(init)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Free()
+
+	ctx := newContext()
+	md := coordinateMetadataCreate(ctx, p.p, epoch)
+	if md == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	return newProj(ctx, md), nil
+}
+
+// Epoch returns the coordinate epoch of the projection, if it was created
+// with coordinate metadata (see NewWithEpoch).
+func (p *Proj) Epoch() (float64, bool) {
+	epoch := coordinateMetadataEpoch(p.context(), p.p)
+	if math.IsNaN(epoch) {
+		return 0, false
+	}
+	return epoch, true
+}
+
+// FrameReferenceEpoch returns the frame reference epoch (as decimal year) of
+// the datum of a dynamic CRS, e.g. 2010.0 for ITRF2014. Returns false if the
+// datum is not dynamic.
+func (p *Proj) FrameReferenceEpoch() (float64, bool, error) {
+	datum := C.proj_crs_get_datum_forced(p.context(), p.p)
+	if datum == nil {
+		return 0, false, ctxError(p.context())
+	}
+	defer C.proj_destroy(datum)
+
+	tp := C.proj_get_type(datum)
+	if tp != C.PJ_TYPE_DYNAMIC_GEODETIC_REFERENCE_FRAME && tp != C.PJ_TYPE_DYNAMIC_VERTICAL_REFERENCE_FRAME {
+		return 0, false, nil
+	}
+	epoch := C.proj_dynamic_datum_get_frame_reference_epoch(p.context(), datum)
+	if epoch == -1 {
+		return 0, false, ctxError(p.context())
+	}
+	return float64(epoch), true, nil
+}
+
+// newProj returns a new Proj for pj, which needs to be created with ctx, or
+// with the shared lookup context if ctx is nil. Proj takes ownership of pj
+// and ctx.
+func newProj(ctx *C.PJ_CONTEXT, pj *C.PJ) *Proj {
+	p := &Proj{p: pj, ctx: ctx}
+	runtime.SetFinalizer(p, free)
+	return p
+}
+
+// newContext creates a new PROJ context with the default settings of this package.
+func newContext() *C.PJ_CONTEXT {
+	ctx := C.proj_context_create()
+	if ctx == nil {
+		return nil
+	}
+	contexts.register(ctx)
+	if atomic.LoadInt32(&networkDisabled) != 0 {
+		C.proj_context_set_enable_network(ctx, 0)
+	}
+	return ctx
+}
+
+var networkDisabled int32
+
+// DisableNetwork disables the network access of PROJ (e.g. for downloading
+// grids from the PROJ CDN) for all projections created afterwards,
+// regardless of the PROJ_NETWORK environment variable or proj.ini.
+func DisableNetwork() {
+	atomic.StoreInt32(&networkDisabled, 1)
+}
+
+// NetworkEnabled returns whether network access is enabled for new
+// projections, based on DisableNetwork, the PROJ_NETWORK environment variable
+// and proj.ini.
+func NetworkEnabled() bool {
+	ctx := newContext()
+	defer destroyContext(ctx)
+	return C.proj_context_is_network_enabled(ctx) != 0
+}
+
+// GridCacheDir returns the directory where PROJ caches grids that are
+// downloaded from the network (cache.db), or an empty string if it is
+// unknown. The directory is not created if it does not exist.
+func GridCacheDir() string {
+	ctx := newContext()
+	defer destroyContext(ctx)
+	dir := C.proj_context_get_user_writable_directory(ctx, 0)
+	if dir == nil {
+		return ""
+	}
+	return C.GoString(dir)
+}
+
+// networkEnabled returns whether network access is enabled for the context
+// of the projection.
+func (p *Proj) networkEnabled() bool {
+	return C.proj_context_is_network_enabled(p.context()) != 0
+}
+
 func free(p *Proj) {
 	p.Free()
 }
@@ -94,11 +307,16 @@ func free(p *Proj) {
 // Free deallocates the projection immediately. Proj will be deallocated on garbage collection otherwise.
 func (p *Proj) Free() {
 	if p.p != nil {
+		if p.ctx == nil {
+			// still bound to the shared lookup context
+			lookup.Lock()
+			defer lookup.Unlock()
+		}
 		C.proj_destroy(p.p)
 		p.p = nil
 	}
 	if p.ctx != nil {
-		C.proj_context_destroy(p.ctx)
+		destroyContext(p.ctx)
 		p.ctx = nil
 	}
 }
@@ -106,72 +324,659 @@ func (p *Proj) Free() {
 // NormalizeForVisualization converts axis order so that coordinates are always
 // x/y or long/lat axis order. The EPSG axis order is ignored when calling
 // Transform.
+// Normalization can not be undone. Create a new projection (or Clone the
+// projection before normalizing) if you need both axis orders.
 func (p *Proj) NormalizeForVisualization() error {
 	if p.normalized {
 		return nil
 	}
 	// Try to normalize for visualization.
-	normProj := C.proj_normalize_for_visualization(p.ctx, p.p)
+	normProj := C.proj_normalize_for_visualization(p.context(), p.p)
 	if normProj == nil {
-		errno := C.proj_context_errno(p.ctx)
-		return errors.New(C.GoString(C.proj_context_errno_string(p.ctx, errno)))
+		return ctxError(p.context())
 	}
 
 	C.proj_destroy(p.p)
 	p.p = normProj
 	p.normalized = true
+	p.key = nil
+	p.axes = nil
 	return nil
 }
 
+// Normalized returns whether NormalizeForVisualization was called for the
+// projection.
+func (p *Proj) Normalized() bool {
+	return p.normalized
+}
+
+// Clone returns an independent copy of the projection, including the
+// normalized state.
+func (p *Proj) Clone() (*Proj, error) {
+	ctx := newContext()
+	clone := C.proj_clone(ctx, p.p)
+	// p must not be finalized while PROJ clones p.p.
+	runtime.KeepAlive(p)
+	if clone == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	c := newProj(ctx, clone)
+	c.normalized = p.normalized
+	return c, nil
+}
+
+// Coord is a coordinate with up to four dimensions. The memory layout of
+// Coord matches PJ_COORD, so that slices of Coord are passed to PROJ without
+// copying.
+//
+// Z is the height for 3D CRS and for vertical CRS. For transformations
+// between two vertical CRS, only Z is transformed. X and Y are the horizontal
+// position in the geographic CRS of the transformation (e.g. lat/lon of
+// NAD83 for NAVD88 heights), which is required for grid based
+// transformations, but they are not modified.
 type Coord struct {
 	X, Y float64
 	Z    float64
 	T    float64
 }
 
+// Compile time check that Coord and PJ_COORD are of the same size.
+var (
+	_ [unsafe.Sizeof(Coord{}) - unsafe.Sizeof(C.PJ_COORD{})]struct{}
+	_ [unsafe.Sizeof(C.PJ_COORD{}) - unsafe.Sizeof(Coord{})]struct{}
+)
+
+// CoordByteSize is the size of a Coord in bytes.
+const CoordByteSize = int(unsafe.Sizeof(Coord{}))
+
+// DimsByteSize returns the size in bytes of a coordinate with dims
+// dimensions (float64 each), e.g. 16 for X/Y or 24 for X/Y/Z. Returns 0 for
+// dims outside of 1 to 4.
+func DimsByteSize(dims int) int {
+	if dims < 1 || dims > 4 {
+		return 0
+	}
+	return dims * int(unsafe.Sizeof(float64(0)))
+}
+
+// pjCoordLayout returns the size of PJ_COORD and the offsets of x, y, z and t.
+func pjCoordLayout() (size uintptr, offsets [4]uintptr) {
+	var c C.PJ_XYZT
+	return unsafe.Sizeof(C.PJ_COORD{}), [4]uintptr{
+		unsafe.Offsetof(c.x),
+		unsafe.Offsetof(c.y),
+		unsafe.Offsetof(c.z),
+		unsafe.Offsetof(c.t),
+	}
+}
+
 func XY(x, y float64) Coord {
 	return Coord{X: x, Y: y, Z: 0, T: math.MaxFloat64}
 }
 
+// SwapXY swaps the X and Y components of all coordinates in-place, e.g. to
+// convert lat/lon coordinates to lon/lat. See Proj.AxisOrderMatches.
+func SwapXY(pts []Coord) {
+	for i := range pts {
+		pts[i].X, pts[i].Y = pts[i].Y, pts[i].X
+	}
+}
+
+// ScaleXY returns c with X and Y multiplied by factor, e.g. to convert
+// coordinates in feet into meters (see Proj.UnitToMeters).
+func (c Coord) ScaleXY(factor float64) Coord {
+	c.X *= factor
+	c.Y *= factor
+	return c
+}
+
+// Distance returns the planar (Euclidean) distance between the X and Y
+// components of c and other, in the unit of the coordinates. This is not
+// the geodesic distance, and meaningless for geographic coordinates.
+func (c Coord) Distance(other Coord) float64 {
+	return math.Hypot(c.X-other.X, c.Y-other.Y)
+}
+
+// Distance3D returns the planar (Euclidean) distance between the X, Y and Z
+// components of c and other, see Distance.
+func (c Coord) Distance3D(other Coord) float64 {
+	dx, dy, dz := c.X-other.X, c.Y-other.Y, c.Z-other.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// ApproxEqual returns whether all components (X, Y, Z and T) of c and other
+// differ by no more than tol.
+func (c Coord) ApproxEqual(other Coord, tol float64) bool {
+	return approxEqual(c.X, other.X, tol) &&
+		approxEqual(c.Y, other.Y, tol) &&
+		approxEqual(c.Z, other.Z, tol) &&
+		approxEqual(c.T, other.T, tol)
+}
+
+func approxEqual(a, b, tol float64) bool {
+	return a == b || math.Abs(a-b) <= tol
+}
+
+// TransformChunkSize is the maximum number of coordinates that are passed to
+// PROJ in a single call. Transform splits larger slices into multiple calls,
+// so that long transformations do not hold the C side for too long.
+// Chunking is disabled if TransformChunkSize is <= 0.
+var TransformChunkSize = 65536
+
 // Transform coordinates to dst projection. Transforms coordinates in-place.
+//
+// All four components of each Coord are written back. T is the coordinate
+// epoch (as decimal year) for time-dependent transformations. PROJ uses T,
+// but passes it through unchanged.
 func (p *Proj) Transform(dst *Proj, pts []Coord) error {
+	return p.transform(dst, pts, TransformOptions{}, nil)
+}
+
+// transform coordinates to dst projection. Sets lastOp to the operation that
+// was used for the last coordinate, if lastOp is not nil.
+func (p *Proj) transform(dst *Proj, pts []Coord, opts TransformOptions, lastOp **usedOperation) error {
+	return p.transformWith(dst, pts, opts, lastOp, transArrayDir)
+}
+
+// transFunc transforms pts in-place with the transformation tr.
+type transFunc func(tr *transformation, pts []Coord) error
+
+// transArrayDir transforms pts with tr in the direction of the
+// transformation.
+func transArrayDir(tr *transformation, pts []Coord) error {
+	return transArray(tr.ctx, tr.pj, tr.dir, pts)
+}
+
+// transformWith validates pts, acquires the transformation to dst and
+// transforms pts with trans, like transform. trans is retried on network
+// errors (see TransformOptions.NetworkRetries).
+func (p *Proj) transformWith(dst *Proj, pts []Coord, opts TransformOptions, lastOp **usedOperation, trans transFunc) error {
 	if p == nil {
 		return errors.New("missing/invalid projection")
 	}
 	if dst == nil {
 		return errors.New("missing/invalid dst projection")
 	}
-	if pts == nil {
+	if len(pts) == 0 {
 		return nil
 	}
 
-	tr := C.proj_create_crs_to_crs_from_pj(p.ctx, p.p, dst.p, nil, nil)
+	if opts.RejectNonFinite {
+		if err := validateFinite(pts); err != nil {
+			return err
+		}
+	}
+
+	if opts.ValidateGeographic && p.IsLatLong() {
+		if err := p.validateGeographic(pts, opts); err != nil {
+			return err
+		}
+	}
+
+	tr, release, err := acquireTransformation(p, dst, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// The transformation can use the context of p (see
+	// acquireTransformation), p and dst must not be finalized while it is in
+	// use.
+	defer runtime.KeepAlive(dst)
+	defer runtime.KeepAlive(p)
 
-	r := C.proj_trans_array(tr, C.PJ_FWD, C.ulong(len(pts)), (*C.PJ_COORD)(unsafe.Pointer(&pts[0])))
+	if opts.NetworkRetries > 0 && C.proj_context_is_network_enabled(tr.ctx) != 0 {
+		err = retryOnNetworkError(pts, opts.NetworkRetries, opts.NetworkRetryBackoff, func(pts []Coord) error {
+			return trans(tr, pts)
+		})
+	} else {
+		err = trans(tr, pts)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.LonWrap != 0 {
+		dst.wrapLongitudes(pts, opts.LonWrap)
+	}
+
+	if lastOp != nil {
+		// Only keep the operation, it is described by LastUsedOperation.
+		*lastOp = newUsedOperation(lastUsedOperation(tr.pj))
+	}
+	return nil
+}
 
-	if r != 0 {
-		errnoRef := C.proj_context_errno(p.ctx)
-		if errnoRef == 0 {
-			return errors.New("unknown error")
+// retryOnNetworkError calls trans with pts and retries up to retries times
+// if it fails with ErrNetwork. pts are restored to their input before each
+// retry. The delay before the first retry is backoff, and it is doubled for
+// each further retry.
+func retryOnNetworkError(pts []Coord, retries int, backoff time.Duration, trans func(pts []Coord) error) error {
+	// PROJ transforms in-place, keep the input for the retries.
+	scratch := getScratch(len(pts))
+	defer putScratch(scratch)
+	orig := *scratch
+	copy(orig, pts)
+	for attempt := 0; ; attempt++ {
+		err := trans(pts)
+		if err == nil || attempt >= retries || !errors.Is(err, ErrNetwork) {
+			return err
 		}
-		return errors.New(C.GoString(C.proj_context_errno_string(p.ctx, errnoRef)))
+		copy(pts, orig)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
+}
+
+// transArray transforms pts with the operation pj in chunks of
+// TransformChunkSize.
+func transArray(ctx *C.PJ_CONTEXT, pj *C.PJ, dir C.PJ_DIRECTION, pts []Coord) error {
+	for offset := 0; offset < len(pts); {
+		chunk := pts[offset:]
+		if TransformChunkSize > 0 && len(chunk) > TransformChunkSize {
+			chunk = chunk[:TransformChunkSize]
+		}
+		r := C.proj_trans_array(pj, dir, C.ulong(len(chunk)), (*C.PJ_COORD)(unsafe.Pointer(&chunk[0])))
+
+		if r != 0 {
+			return &TransformError{
+				Index: failedIndex(chunk, offset),
+				Err:   ctxError(ctx),
+			}
+		}
+		offset += len(chunk)
+	}
+	return nil
+}
+
+// TransformError is returned by Transform if PROJ fails to transform
+// coordinates.
+type TransformError struct {
+	// Index of the first coordinate that failed, or -1 if unknown.
+	Index int
+	// Err is the error reported by PROJ.
+	Err error
+}
+
+func (e *TransformError) Error() string {
+	if e.Index < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("coordinate %d failed: %s", e.Index, e.Err)
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+// failedIndex returns the index of the first coordinate in pts that PROJ
+// failed to transform, plus offset. PROJ sets all components of failed
+// coordinates to HUGE_VAL. Returns -1 if there is no such coordinate.
+func failedIndex(pts []Coord, offset int) int {
+	for i, pt := range pts {
+		if math.IsInf(pt.X, 1) && math.IsInf(pt.Y, 1) {
+			return offset + i
+		}
+	}
+	return -1
+}
+
+// InvalidCoordinateError is returned by Transform for coordinates outside of
+// the valid latitude or longitude range of a geographic source projection
+// (see TransformOptions.ValidateGeographic), and for non-finite coordinates
+// (see TransformOptions.RejectNonFinite).
+type InvalidCoordinateError struct {
+	// Index of the coordinate in the transformed slice.
+	Index int
+	// Component is either "latitude" or "longitude", or "X" or "Y" for
+	// non-finite coordinates.
+	Component string
+	// Value of the component, and the valid range in units of the CRS.
+	Value, Min, Max float64
+}
+
+func (e *InvalidCoordinateError) Error() string {
+	if math.IsNaN(e.Value) || math.IsInf(e.Value, 0) {
+		return fmt.Sprintf("Invalid coordinate %d: %s is %v", e.Index, e.Component, e.Value)
+	}
+	return fmt.Sprintf("Invalid coordinate %d: %s %v out of range [%v, %v]",
+		e.Index, e.Component, e.Value, e.Min, e.Max)
+}
+
+func (e *InvalidCoordinateError) Is(target error) bool {
+	return target == ErrInvalidCoordinate
+}
+
+// validateFinite checks that X and Y of all pts are finite.
+func validateFinite(pts []Coord) error {
+	for i, pt := range pts {
+		if err := checkFinite(i, pt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// checkFinite returns an InvalidCoordinateError if X or Y of pt is NaN or
+// infinite. i is the index of pt for the error.
+func checkFinite(i int, pt Coord) error {
+	if math.IsNaN(pt.X) || math.IsInf(pt.X, 0) {
+		return &InvalidCoordinateError{Index: i, Component: "X", Value: pt.X}
+	}
+	if math.IsNaN(pt.Y) || math.IsInf(pt.Y, 0) {
+		return &InvalidCoordinateError{Index: i, Component: "Y", Value: pt.Y}
+	}
 	return nil
 }
 
+// axisInfo is the axis information of a projection, see geographicAxes.
+type axisInfo struct {
+	latFirst bool
+	fromDeg  float64
+	ok       bool
+}
+
+// geographicAxes returns whether the first axis of the geographic projection
+// p is the latitude, and the factor to convert from degree into the unit of
+// the axes. ok is false if the axis information is not available. The
+// result is cached for the projection.
+func (p *Proj) geographicAxes() (latFirst bool, fromDeg float64, ok bool) {
+	if p.axes == nil {
+		a := p.queryAxes()
+		p.axes = &a
+	}
+	return p.axes.latFirst, p.axes.fromDeg, p.axes.ok
+}
+
+// queryAxes returns the axis information of the projection from PROJ.
+func (p *Proj) queryAxes() axisInfo {
+	ctx, unlock := p.metadataContext()
+	defer unlock()
+	cs := C.proj_crs_get_coordinate_system(ctx, p.p)
+	if cs == nil {
+		return axisInfo{}
+	}
+	defer C.proj_destroy(cs)
+
+	var direction *C.char
+	var factor C.double
+	r := C.proj_cs_get_axis_info(ctx, cs, 0,
+		nil,        // out_name
+		nil,        // out_abbrev
+		&direction, // out_direction
+		&factor,    // out_unit_conv_factor
+		nil,        // out_unit_name
+		nil,        // out_unit_auth_name
+		nil,        // out_unit_code
+	)
+	if r == 0 || factor <= 0 {
+		return axisInfo{}
+	}
+
+	a := axisInfo{ok: true}
+	if direction != nil {
+		dir := strings.ToLower(C.GoString(direction))
+		a.latFirst = dir == "north" || dir == "south"
+	}
+
+	a.fromDeg = math.Pi / 180 / float64(factor)
+	if math.Abs(a.fromDeg-1) < 1e-12 {
+		a.fromDeg = 1
+	}
+	return a
+}
+
+// AxisOrderMatches returns whether the axis order of the projection is
+// lon/lat (or east/north) if isLonLat is true, or lat/lon (or north/east) if
+// isLonLat is false. Coordinates need to be swapped with SwapXY if the axis
+// order does not match.
+func (p *Proj) AxisOrderMatches(isLonLat bool) (bool, error) {
+	latFirst, _, ok := p.geographicAxes()
+	if !ok {
+		return false, errors.New("axis order of projection not available")
+	}
+	return latFirst != isLonLat, nil
+}
+
+// LikelyWrongAxisOrder returns whether the coordinates are likely in the
+// wrong axis order for the geographic projection p, e.g. lon/lat coordinates
+// for EPSG:4326 (lat/lon). This is the case if a coordinate has a latitude
+// outside of [-90, 90] that would be a valid latitude with swapped X and Y.
+// Returns false for non-geographic projections. Note that coordinates with
+// latitude and longitude within [-90, 90] can not be detected.
+func (p *Proj) LikelyWrongAxisOrder(pts []Coord) bool {
+	if !p.IsLatLong() {
+		return false
+	}
+	latFirst, fromDeg, ok := p.geographicAxes()
+	if !ok {
+		return false
+	}
+	maxLat := 90 * fromDeg
+	for _, pt := range pts {
+		lat, lon := pt.Y, pt.X
+		if latFirst {
+			lat, lon = lon, lat
+		}
+		if math.Abs(lat) > maxLat && math.Abs(lon) <= maxLat {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGeographic checks that all pts are within the valid latitude and
+// longitude range of the geographic projection p (see
+// TransformOptions.ValidateGeographic).
+func (p *Proj) validateGeographic(pts []Coord, opts TransformOptions) error {
+	r, ok := p.geographicRange(opts)
+	if !ok {
+		// Leave validation to PROJ.
+		return nil
+	}
+	for i, pt := range pts {
+		if err := r.check(i, pt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// geographicRange is the valid range of coordinates of a geographic
+// projection, in the units of the projection.
+type geographicRange struct {
+	latFirst       bool
+	minLat, maxLat float64
+	minLon, maxLon float64
+}
+
+// geographicRange returns the valid range of coordinates of the geographic
+// projection for opts. ok is false if the axes are unknown.
+func (p *Proj) geographicRange(opts TransformOptions) (r geographicRange, ok bool) {
+	latFirst, fromDeg, ok := p.geographicAxes()
+	if !ok {
+		return geographicRange{}, false
+	}
+	minLon, maxLon := opts.longitudeRange()
+	return geographicRange{
+		latFirst: latFirst,
+		minLat:   -90 * fromDeg,
+		maxLat:   90 * fromDeg,
+		minLon:   minLon * fromDeg,
+		maxLon:   maxLon * fromDeg,
+	}, true
+}
+
+// check returns an InvalidCoordinateError for pt if it is out of range. i is
+// the index of pt for the error.
+func (r geographicRange) check(i int, pt Coord) error {
+	lon, lat := pt.X, pt.Y
+	if r.latFirst {
+		lon, lat = lat, lon
+	}
+	if lat < r.minLat || lat > r.maxLat {
+		return &InvalidCoordinateError{Index: i, Component: "latitude", Value: lat, Min: r.minLat, Max: r.maxLat}
+	}
+	if lon < r.minLon || lon > r.maxLon {
+		return &InvalidCoordinateError{Index: i, Component: "longitude", Value: lon, Min: r.minLon, Max: r.maxLon}
+	}
+	return nil
+}
+
+// WrapLongitudes wraps all longitudes of pts into the range of [-180, 180]
+// degree. Does nothing if p is not a geographic projection. Respects the axis
+// order of the projection.
+func (p *Proj) WrapLongitudes(pts []Coord) {
+	if !p.IsLatLong() {
+		return
+	}
+	latFirst, fromDeg, ok := p.geographicAxes()
+	if !ok {
+		return
+	}
+	for i := range pts {
+		lon := &pts[i].X
+		if latFirst {
+			lon = &pts[i].Y
+		}
+		*lon = wrapLongitude(*lon, 180*fromDeg)
+	}
+}
+
+// clampLatitudes clamps all latitudes of pts into the range of [-max, max]
+// degree, if p is a geographic projection.
+func (p *Proj) clampLatitudes(pts []Coord, max float64) {
+	if !p.IsLatLong() {
+		return
+	}
+	latFirst, fromDeg, ok := p.geographicAxes()
+	if !ok {
+		return
+	}
+	max *= fromDeg
+	for i := range pts {
+		lat := &pts[i].Y
+		if latFirst {
+			lat = &pts[i].X
+		}
+		if *lat > max {
+			*lat = max
+		} else if *lat < -max {
+			*lat = -max
+		}
+	}
+}
+
+// wrapLongitudes wraps all longitudes to [center-180, center+180] (in
+// degree), if p is a geographic projection.
+func (p *Proj) wrapLongitudes(pts []Coord, center float64) {
+	wrap := p.lonWrapper(center)
+	if wrap == nil {
+		return
+	}
+	for i := range pts {
+		wrap(&pts[i])
+	}
+}
+
+// lonWrapper returns a function that wraps the longitude of a coordinate to
+// [center-180, center+180] (in degree), or nil if p is not a geographic
+// projection.
+func (p *Proj) lonWrapper(center float64) func(c *Coord) {
+	if !p.IsLatLong() {
+		return nil
+	}
+	latFirst, fromDeg, ok := p.geographicAxes()
+	if !ok {
+		return nil
+	}
+	center *= fromDeg
+	return func(c *Coord) {
+		lon := &c.X
+		if latFirst {
+			lon = &c.Y
+		}
+		if math.IsInf(*lon, 0) || math.IsNaN(*lon) {
+			return
+		}
+		*lon = center + wrapLongitude(*lon-center, 180*fromDeg)
+	}
+}
+
+// wrapLongitude wraps lon into the range of [-half, half].
+func wrapLongitude(lon, half float64) float64 {
+	if lon >= -half && lon <= half {
+		return lon
+	}
+	lon = math.Mod(lon+half, 2*half)
+	if lon < 0 {
+		lon += 2 * half
+	}
+	return lon - half
+}
+
+// isEquivalentTo returns whether p and other describe the same CRS, ignoring
+// names and other metadata.
+func (p *Proj) isEquivalentTo(other *Proj) bool {
+	if p == nil || other == nil {
+		return false
+	}
+	return C.proj_is_equivalent_to_with_ctx(p.context(), p.p, other.p, C.PJ_COMP_EQUIVALENT) != 0
+}
+
 // IsLatLong returns whether the projection uses lat/long coordinates, instead projected.
 func (p *Proj) IsLatLong() bool {
 	tp := C.proj_get_type(p.p)
 	return tp == C.PJ_TYPE_GEODETIC_CRS || tp == C.PJ_TYPE_GEOGRAPHIC_2D_CRS || tp == C.PJ_TYPE_GEOGRAPHIC_3D_CRS
 }
 
+// IsGeocentric returns whether the projection is a geocentric CRS (earth
+// centered, earth fixed), with cartesian X, Y and Z coordinates, instead of
+// geographic coordinates with ellipsoidal heights.
+func (p *Proj) IsGeocentric() (bool, error) {
+	switch C.proj_get_type(p.p) {
+	case C.PJ_TYPE_GEOCENTRIC_CRS:
+		return true, nil
+	case C.PJ_TYPE_GEODETIC_CRS:
+		cs, err := p.CoordinateSystemType()
+		if err != nil {
+			return false, err
+		}
+		return cs == CSCartesian, nil
+	}
+	return false, nil
+}
+
 // Definition returns projection description.
 func (p *Proj) Description() string {
+	_, unlock := p.metadataContext()
+	defer unlock()
 	info := C.proj_pj_info(p.p)
 	return strings.TrimSpace(C.GoString(info.description))
 }
 
+// Name returns the name of the CRS (e.g. "WGS 84" or "ETRS89 / UTM zone 32N").
+func (p *Proj) Name() (string, error) {
+	name := C.proj_get_name(p.p)
+	if name == nil {
+		return "", errors.New("projection has no name")
+	}
+	return C.GoString(name), nil
+}
+
+// Scope returns the intended scope of the CRS (e.g. "Engineering survey,
+// topographic mapping."), or an empty string if the CRS has no scope.
+func (p *Proj) Scope() (string, error) {
+	if p == nil {
+		return "", errors.New("missing/invalid projection")
+	}
+	scope := C.proj_get_scope(p.p)
+	if scope == nil {
+		return "", nil
+	}
+	return C.GoString(scope), nil
+}
+
 func (p *Proj) String() string {
 	return "Proj(" + p.Description() + ")"
 }
@@ -181,10 +986,12 @@ func (p *Proj) String() string {
 func (p *Proj) UnitName() string {
 	var unitName *C.char = nil
 
-	crs := C.proj_crs_get_coordinate_system(p.ctx, p.p)
+	ctx, unlock := p.metadataContext()
+	defer unlock()
+	crs := C.proj_crs_get_coordinate_system(ctx, p.p)
 	defer C.proj_destroy(crs)
 
-	r := C.proj_cs_get_axis_info(p.ctx, crs, 0,
+	r := C.proj_cs_get_axis_info(ctx, crs, 0,
 		nil,       // out_name
 		nil,       // out_abbrev
 		nil,       // out_direction
@@ -203,18 +1010,767 @@ func (p *Proj) UnitName() string {
 	return ""
 }
 
+// UnitToMeters returns the factor to convert coordinates in the unit of the
+// first axis into meters, e.g. 0.3048 for foot or 0.30480061 for US survey
+// foot. Returns an error for CRS with angular units (geographic CRS). See
+// Coord.ScaleXY.
+func (p *Proj) UnitToMeters() (float64, error) {
+	csType, err := p.CoordinateSystemType()
+	if err != nil {
+		return 0, err
+	}
+	if csType != CSCartesian && csType != CSVertical {
+		return 0, fmt.Errorf("%s coordinate system has no linear unit", csType)
+	}
+
+	cs := C.proj_crs_get_coordinate_system(p.context(), p.p)
+	if cs == nil {
+		return 0, ctxError(p.context())
+	}
+	defer C.proj_destroy(cs)
+
+	var factor C.double
+	r := C.proj_cs_get_axis_info(p.context(), cs, 0,
+		nil,     // out_name
+		nil,     // out_abbrev
+		nil,     // out_direction
+		&factor, // out_unit_conv_factor
+		nil,     // out_unit_name
+		nil,     // out_unit_auth_name
+		nil,     // out_unit_code
+	)
+	if r == 0 || factor <= 0 {
+		return 0, ctxError(p.context())
+	}
+	return float64(factor), nil
+}
+
+// AxisCount returns the number of axes of the coordinate system, e.g. 2 for
+// 2D geographic or projected CRS and 3 for 3D geographic CRS.
+func (p *Proj) AxisCount() (int, error) {
+	cs := C.proj_crs_get_coordinate_system(p.context(), p.p)
+	if cs == nil {
+		return 0, ctxError(p.context())
+	}
+	defer C.proj_destroy(cs)
+
+	n := C.proj_cs_get_axis_count(p.context(), cs)
+	if n < 0 {
+		return 0, ctxError(p.context())
+	}
+	return int(n), nil
+}
+
+// CSType is the type of the coordinate system of a CRS.
+type CSType int
+
+const (
+	CSUnknown CSType = iota
+	// CSCartesian is used by projected and geocentric CRS.
+	CSCartesian
+	// CSEllipsoidal is used by geographic CRS.
+	CSEllipsoidal
+	// CSVertical is used by vertical CRS.
+	CSVertical
+	CSSpherical
+	CSOrdinal
+	CSParametric
+	CSDateTimeTemporal
+	CSTemporalCount
+	CSTemporalMeasure
+)
+
+func (t CSType) String() string {
+	switch t {
+	case CSCartesian:
+		return "cartesian"
+	case CSEllipsoidal:
+		return "ellipsoidal"
+	case CSVertical:
+		return "vertical"
+	case CSSpherical:
+		return "spherical"
+	case CSOrdinal:
+		return "ordinal"
+	case CSParametric:
+		return "parametric"
+	case CSDateTimeTemporal:
+		return "datetime temporal"
+	case CSTemporalCount:
+		return "temporal count"
+	case CSTemporalMeasure:
+		return "temporal measure"
+	default:
+		return "unknown"
+	}
+}
+
+// CoordinateSystemType returns the type of the coordinate system, e.g.
+// CSEllipsoidal for geographic CRS or CSCartesian for projected CRS. Returns
+// an error for CRS without a single coordinate system (e.g. compound CRS).
+func (p *Proj) CoordinateSystemType() (CSType, error) {
+	cs := C.proj_crs_get_coordinate_system(p.context(), p.p)
+	if cs == nil {
+		return CSUnknown, ctxError(p.context())
+	}
+	defer C.proj_destroy(cs)
+
+	switch C.proj_cs_get_type(p.context(), cs) {
+	case C.PJ_CS_TYPE_CARTESIAN:
+		return CSCartesian, nil
+	case C.PJ_CS_TYPE_ELLIPSOIDAL:
+		return CSEllipsoidal, nil
+	case C.PJ_CS_TYPE_VERTICAL:
+		return CSVertical, nil
+	case C.PJ_CS_TYPE_SPHERICAL:
+		return CSSpherical, nil
+	case C.PJ_CS_TYPE_ORDINAL:
+		return CSOrdinal, nil
+	case C.PJ_CS_TYPE_PARAMETRIC:
+		return CSParametric, nil
+	case C.PJ_CS_TYPE_DATETIMETEMPORAL:
+		return CSDateTimeTemporal, nil
+	case C.PJ_CS_TYPE_TEMPORALCOUNT:
+		return CSTemporalCount, nil
+	case C.PJ_CS_TYPE_TEMPORALMEASURE:
+		return CSTemporalMeasure, nil
+	default:
+		return CSUnknown, ctxError(p.context())
+	}
+}
+
+var (
+	// ErrProj matches all errors reported by PROJ (e.g. invalid projection
+	// definitions or failed transformations), with errors.Is.
+	ErrProj = errors.New("PROJ error")
+	// ErrInvalidCoordinate matches all InvalidCoordinateErrors and errors
+	// reported by PROJ for invalid coordinates or coordinates outside of the
+	// projection domain, with errors.Is.
+	ErrInvalidCoordinate = errors.New("invalid coordinate")
+	// ErrNetwork matches errors reported by PROJ for failed network access
+	// (e.g. failed downloads of grids), with errors.Is. See
+	// TransformOptions.NetworkRetries.
+	ErrNetwork = errors.New("PROJ network error")
+)
+
+// projError is an error reported by PROJ.
+type projError struct {
+	msg   string
+	errno int
+}
+
+func (e *projError) Error() string {
+	return e.msg
+}
+
+// PROJ error codes that are matched by ErrNetwork and ErrInvalidCoordinate.
+const (
+	errnoNetwork       = C.PROJ_ERR_OTHER_NETWORK_ERROR
+	errnoInvalidCoord  = C.PROJ_ERR_COORD_TRANSFM_INVALID_COORD
+	errnoOutsideDomain = C.PROJ_ERR_COORD_TRANSFM_OUTSIDE_PROJECTION_DOMAIN
+)
+
+func (e *projError) Is(target error) bool {
+	switch target {
+	case ErrProj:
+		return true
+	case ErrNetwork:
+		return e.errno == errnoNetwork
+	case ErrInvalidCoordinate:
+		return e.errno == errnoInvalidCoord || e.errno == errnoOutsideDomain
+	}
+	return false
+}
+
+// ctxError returns the last error of the context.
+func ctxError(ctx *C.PJ_CONTEXT) error {
+	errno := C.proj_context_errno(ctx)
+	if errno == 0 {
+		return &projError{msg: "unknown error"}
+	}
+	return &projError{
+		msg:   C.GoString(C.proj_context_errno_string(ctx, errno)),
+		errno: int(errno),
+	}
+}
+
+// IsBound returns whether the projection is a bound CRS, e.g. a CRS with a
+// +towgs84 datum shift.
+func (p *Proj) IsBound() (bool, error) {
+	return C.proj_get_type(p.p) == C.PJ_TYPE_BOUND_CRS, nil
+}
+
+// Unbound returns a new projection of the CRS without the datum shift
+// binding. Returns an error if p is not a bound CRS.
+func (p *Proj) Unbound() (*Proj, error) {
+	if C.proj_get_type(p.p) != C.PJ_TYPE_BOUND_CRS {
+		return nil, errors.New("projection is not a bound CRS")
+	}
+	ctx := newContext()
+	base := C.proj_get_source_crs(ctx, p.p)
+	if base == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	return newProj(ctx, base), nil
+}
+
+// BoundToWGS84 returns a new projection of the CRS, bound to WGS 84 with the
+// datum shift (like +towgs84) known by PROJ. Returns an error if no such
+// transformation is known, e.g. for WGS 84 itself.
+func (p *Proj) BoundToWGS84() (*Proj, error) {
+	ctx := newContext()
+	bound := C.proj_crs_create_bound_crs_to_WGS84(ctx, p.p, nil)
+	if bound == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	if C.proj_get_type(bound) != C.PJ_TYPE_BOUND_CRS {
+		C.proj_destroy(bound)
+		destroyContext(ctx)
+		return nil, errors.New("no transformation to WGS 84 known")
+	}
+	return newProj(ctx, bound), nil
+}
+
+// BaseGeographic returns a new projection of the geographic CRS the CRS is
+// based on, e.g. ETRS89 for ETRS89 / UTM zone 32N. Returns the geographic
+// CRS itself for geographic projections. For geocentric CRS (e.g.
+// EPSG:4978), it returns a geographic CRS (in lat/lon order) with the datum
+// of the geocentric CRS.
+func (p *Proj) BaseGeographic() (*Proj, error) {
+	ctx := newContext()
+	base := C.proj_crs_get_geodetic_crs(ctx, p.p)
+	if base == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	baseProj := newProj(ctx, base)
+	geocentric, err := baseProj.IsGeocentric()
+	if err != nil {
+		baseProj.Free()
+		return nil, err
+	}
+	if !geocentric {
+		return baseProj, nil
+	}
+	defer baseProj.Free()
+	return baseProj.geographicFromDatum()
+}
+
+// geographicFromDatum returns a new geographic CRS with the datum and the
+// name of the geodetic CRS p.
+func (p *Proj) geographicFromDatum() (*Proj, error) {
+	ctx := newContext()
+	datum := C.proj_crs_get_datum_forced(ctx, p.p)
+	if datum == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	defer C.proj_destroy(datum)
+	cs := C.proj_create_ellipsoidal_2D_cs(ctx, C.PJ_ELLPS2D_LATITUDE_LONGITUDE, nil, 0)
+	if cs == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	defer C.proj_destroy(cs)
+
+	geo := C.proj_create_geographic_crs_from_datum(ctx, C.proj_get_name(p.p), datum, cs)
+	if geo == nil {
+		err := ctxError(ctx)
+		destroyContext(ctx)
+		return nil, err
+	}
+	return newProj(ctx, geo), nil
+}
+
+// ToGeographic transforms coordinates to the geographic CRS the projection
+// is based on (see BaseGeographic), or to WGS 84 if there is none.
+// Transformed coordinates are always in lon/lat order. Transforms
+// coordinates in-place.
+func (p *Proj) ToGeographic(pts []Coord) error {
+	geo, err := p.BaseGeographic()
+	if err != nil {
+		geo, err = NewEPSG(4326)
+		if err != nil {
+			return err
+		}
+	}
+	defer geo.Free()
+	if err := geo.NormalizeForVisualization(); err != nil {
+		return err
+	}
+	return p.Transform(geo, pts)
+}
+
+// GDALSRS returns the CRS as WKT1 in the GDAL flavor, as accepted by GDAL
+// (e.g. gdalwarp -s_srs/-t_srs).
+func (p *Proj) GDALSRS() (string, error) {
+	s := C.proj_as_wkt(p.context(), p.p, C.PJ_WKT1_GDAL, nil)
+	if s == nil {
+		return "", ctxError(p.context())
+	}
+	return C.GoString(s), nil
+}
+
 // Transformer projects coordinates from Src to Dst.
 type Transformer struct {
 	Src *Proj
 	Dst *Proj
+
+	// ClampLatitude clamps all latitudes to [-ClampLatitude, ClampLatitude]
+	// (in degree) before transforming, if Src is a geographic projection and
+	// ClampLatitude is > 0. See WebMercatorMaxLatitude.
+	ClampLatitude float64
+
+	// OnTransform is called after each successful Transform with the number
+	// of transformed coordinates and the duration of the transformation.
+	OnTransform func(n int, d time.Duration)
+
+	opts   TransformOptions
+	desc   *OperationDescription
+	lastOp *usedOperation
 }
 
 // Transform coordinates fron src to dst projection. Transforms coordinates in-place.
 func (t *Transformer) Transform(pts []Coord) error {
-	return t.Src.Transform(t.Dst, pts)
+	return t.transformOpts(pts, t.opts)
+}
+
+// TransformArea transforms coordinates like Transform, but only uses
+// coordinate operations that are valid for the area of interest (in
+// degree), instead of TransformOptions.AreaOfInterest of the transformer.
+func (t *Transformer) TransformArea(pts []Coord, west, south, east, north float64) error {
+	opts := t.opts
+	opts.AreaOfInterest = &Area{West: west, South: south, East: east, North: north}
+	return t.transformOpts(pts, opts)
+}
+
+func (t *Transformer) transformOpts(pts []Coord, opts TransformOptions) error {
+	return t.transformOptsWith(pts, opts, transArrayDir)
+}
+
+// transformOptsWith transforms pts with trans, like Transform with opts.
+func (t *Transformer) transformOptsWith(pts []Coord, opts TransformOptions, trans transFunc) error {
+	if t.OnTransform == nil {
+		return t.transformWith(pts, opts, trans)
+	}
+	start := time.Now()
+	if err := t.transformWith(pts, opts, trans); err != nil {
+		return err
+	}
+	t.OnTransform(len(pts), time.Since(start))
+	return nil
+}
+
+func (t *Transformer) transform(pts []Coord, opts TransformOptions) error {
+	return t.transformWith(pts, opts, transArrayDir)
+}
+
+func (t *Transformer) transformWith(pts []Coord, opts TransformOptions, trans transFunc) error {
+	if t.ClampLatitude > 0 && t.Src != nil {
+		t.Src.clampLatitudes(pts, t.ClampLatitude)
+	}
+	if !opts.RecordLastUsedOperation {
+		return t.Src.transformWith(t.Dst, pts, opts, nil, trans)
+	}
+	var lastOp *usedOperation
+	if err := t.Src.transformWith(t.Dst, pts, opts, &lastOp, trans); err != nil {
+		return err
+	}
+	if len(pts) > 0 {
+		t.lastOp = lastOp
+	}
+	return nil
+}
+
+// TransformChan transforms all coordinates from in and sends them to out.
+// Coordinates are transformed in batches of batch coordinates, only the
+// last batch can be smaller. out is closed after in is closed and all
+// coordinates are sent, or after an error. All remaining coordinates of in
+// are discarded after an error, so that senders are not blocked.
+// TransformChan only returns after in is closed.
+func (t *Transformer) TransformChan(in <-chan Coord, out chan<- Coord, batch int) error {
+	defer close(out)
+	if batch < 1 {
+		batch = 1
+	}
+	buf := make([]Coord, 0, batch)
+	flush := func() error {
+		if err := t.Transform(buf); err != nil {
+			return err
+		}
+		for _, c := range buf {
+			out <- c
+		}
+		buf = buf[:0]
+		return nil
+	}
+	for c := range in {
+		buf = append(buf, c)
+		if len(buf) < batch {
+			continue
+		}
+		if err := flush(); err != nil {
+			drain(in)
+			return err
+		}
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	return flush()
+}
+
+// drain receives all values from in until it is closed.
+func drain(in <-chan Coord) {
+	for range in {
+	}
+}
+
+// TransformRadians transforms coordinates from src to dst projection, like
+// Transform. Coordinates of geographic projections are in radians instead
+// of degree. Transforms coordinates in-place. pts are not modified if an
+// error is returned.
+func (t *Transformer) TransformRadians(pts []Coord) error {
+	buf := getScratch(len(pts))
+	defer putScratch(buf)
+	tmp := *buf
+	copy(tmp, pts)
+	if t.Src != nil && t.Src.IsLatLong() {
+		scaleXY(tmp, 180/math.Pi)
+	}
+	if err := t.Transform(tmp); err != nil {
+		return err
+	}
+	if t.Dst != nil && t.Dst.IsLatLong() {
+		scaleXY(tmp, math.Pi/180)
+	}
+	copy(pts, tmp)
+	return nil
+}
+
+// scaleXY multiplies X and Y of all pts by factor.
+func scaleXY(pts []Coord, factor float64) {
+	for i := range pts {
+		pts[i] = pts[i].ScaleXY(factor)
+	}
+}
+
+// Func returns a function that transforms a single coordinate from src to
+// dst projection. The function keeps the transformation from Src to Dst,
+// which makes it faster than Transform for coordinates that are transformed
+// one by one. Coordinates are not validated and latitudes are not clamped
+// (see ClampLatitude). Longitudes are wrapped if TransformOptions.LonWrap is
+// set. The returned function is not safe for concurrent use.
+func (t *Transformer) Func() (func(Coord) (Coord, error), error) {
+	if t.Src == nil || t.Dst == nil {
+		return nil, errors.New("missing/invalid projection")
+	}
+	tr, release, err := acquireTransformation(t.Src, t.Dst, t.opts)
+	if err != nil {
+		return nil, err
+	}
+	f := &transformFunc{src: t.Src, dst: t.Dst, tr: tr, release: release}
+	runtime.SetFinalizer(f, (*transformFunc).free)
+
+	var wrap func(c *Coord)
+	if t.opts.LonWrap != 0 {
+		wrap = t.Dst.lonWrapper(t.opts.LonWrap)
+	}
+
+	return func(c Coord) (Coord, error) {
+		r := C.proj_trans(f.tr.pj, f.tr.dir, *(*C.PJ_COORD)(unsafe.Pointer(&c)))
+		out := *(*Coord)(unsafe.Pointer(&r))
+		if math.IsInf(out.X, 1) && math.IsInf(out.Y, 1) {
+			err := ctxError(f.tr.ctx)
+			runtime.KeepAlive(f)
+			return c, err
+		}
+		// f must not be finalized (releasing the transformation) while
+		// proj_trans is running.
+		runtime.KeepAlive(f)
+		if wrap != nil {
+			wrap(&out)
+		}
+		return out, nil
+	}, nil
+}
+
+// transformFunc holds the transformation of a function returned by
+// Transformer.Func, and the projections it depends on.
+type transformFunc struct {
+	src, dst *Proj
+	tr       *transformation
+	release  func()
+}
+
+func (f *transformFunc) free() {
+	f.release()
+}
+
+// TransformMaxDelta transforms coordinates from src to dst projection, like
+// Transform, and returns the largest distance between an input coordinate
+// and its transformed coordinate (in X, Y and Z). Transforms coordinates
+// in-place.
+func (t *Transformer) TransformMaxDelta(pts []Coord) (maxDelta float64, err error) {
+	scratch := getScratch(len(pts))
+	defer putScratch(scratch)
+	orig := *scratch
+	copy(orig, pts)
+	if err := t.Transform(pts); err != nil {
+		return 0, err
+	}
+	for i := range pts {
+		if d := pts[i].Distance3D(orig[i]); d > maxDelta {
+			maxDelta = d
+		}
+	}
+	return maxDelta, nil
+}
+
+// TransformWithAccuracy transforms coordinates from src to dst projection,
+// like Transform, and returns the accuracy (in metre) of the coordinate
+// operation that PROJ used for each coordinate, or -1 if the accuracy is
+// unknown. The operation can differ between coordinates if multiple
+// operations apply to different regions (e.g. grids that only cover parts
+// of a country). Coordinates are transformed one by one, which is slower
+// than Transform. Transforms coordinates in-place.
+func (t *Transformer) TransformWithAccuracy(pts []Coord) (accuracies []float64, err error) {
+	accuracies = make([]float64, len(pts))
+	err = t.transformOptsWith(pts, t.opts, func(tr *transformation, pts []Coord) error {
+		for i := range pts {
+			r := C.proj_trans(tr.pj, tr.dir, *(*C.PJ_COORD)(unsafe.Pointer(&pts[i])))
+			pts[i] = *(*Coord)(unsafe.Pointer(&r))
+			if math.IsInf(pts[i].X, 1) && math.IsInf(pts[i].Y, 1) {
+				return &TransformError{Index: i, Err: ctxError(tr.ctx)}
+			}
+			// Only transformations of proj_create_crs_to_crs have multiple
+			// candidate operations.
+			op := lastUsedOperation(tr.pj)
+			if op == nil {
+				accuracies[i] = float64(C.proj_coordoperation_get_accuracy(tr.ctx, tr.pj))
+				continue
+			}
+			accuracies[i] = float64(C.proj_coordoperation_get_accuracy(tr.ctx, op))
+			C.proj_destroy(op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accuracies, nil
+}
+
+// TransformChecked transforms coordinates from src to dst projection, like
+// Transform, and transforms the results back from dst to src to verify the
+// transformation. Returns a ResidualError for the first coordinate where the
+// distance (in X and Y, see Coord.Distance) between the input coordinate and
+// the result of the round trip exceeds maxResidual, in the unit of the src
+// projection. Transforms coordinates in-place, pts contain the results of
+// the forward transformation, even if a ResidualError is returned.
+func (t *Transformer) TransformChecked(pts []Coord, maxResidual float64) error {
+	scratch := getScratch(len(pts))
+	defer putScratch(scratch)
+	orig := *scratch
+	copy(orig, pts)
+	if err := t.Transform(pts); err != nil {
+		return err
+	}
+
+	backScratch := getScratch(len(pts))
+	defer putScratch(backScratch)
+	back := *backScratch
+	copy(back, pts)
+	inv := t.Inverse()
+	if err := inv.Transform(back); err != nil {
+		return fmt.Errorf("inverse transformation: %w", err)
+	}
+	for i := range orig {
+		d := orig[i].Distance(back[i])
+		if d > maxResidual || math.IsNaN(d) {
+			return &ResidualError{Index: i, Residual: d, MaxResidual: maxResidual}
+		}
+	}
+	return nil
+}
+
+// ResidualError is returned by TransformChecked if the round trip residual
+// of a coordinate exceeds the maximum residual.
+type ResidualError struct {
+	// Index of the first coordinate that exceeded the maximum residual.
+	Index int
+	// Residual of the coordinate and the maximum residual, in the unit of
+	// the src projection.
+	Residual, MaxResidual float64
+}
+
+func (e *ResidualError) Error() string {
+	return fmt.Sprintf("coordinate %d: round trip residual %v exceeds %v", e.Index, e.Residual, e.MaxResidual)
+}
+
+// CompareTransforms transforms the coordinates with a and b and returns the
+// maximum and the mean distance (in X, Y and Z) between the results, e.g. to
+// compare different coordinate operations between the same projections.
+// pts are not modified.
+func CompareTransforms(a, b Transformer, pts []Coord) (maxDelta, meanDelta float64, err error) {
+	if len(pts) == 0 {
+		return 0, 0, nil
+	}
+	scratchA, scratchB := getScratch(len(pts)), getScratch(len(pts))
+	defer putScratch(scratchA)
+	defer putScratch(scratchB)
+	ptsA, ptsB := *scratchA, *scratchB
+	copy(ptsA, pts)
+	if err := a.Transform(ptsA); err != nil {
+		return 0, 0, err
+	}
+	copy(ptsB, pts)
+	if err := b.Transform(ptsB); err != nil {
+		return 0, 0, err
+	}
+
+	var sum float64
+	for i := range ptsA {
+		d := ptsA[i].Distance3D(ptsB[i])
+		if d > maxDelta {
+			maxDelta = d
+		}
+		sum += d
+	}
+	return maxDelta, sum / float64(len(pts)), nil
+}
+
+// TransformAny transforms n coordinates that are read with get and written
+// back with set, e.g. to transform coordinates that are stored in custom
+// structs. Coordinates are transformed in batches of up to
+// TransformChunkSize coordinates. The index of returned TransformErrors and
+// InvalidCoordinateErrors refers to i of get and set.
+func (t *Transformer) TransformAny(n int, get func(i int) (x, y, z float64), set func(i int, x, y, z float64)) error {
+	batchSize := TransformChunkSize
+	if batchSize <= 0 || batchSize > n {
+		batchSize = n
+	}
+	scratch := getScratch(batchSize)
+	defer putScratch(scratch)
+	buf := *scratch
+	for offset := 0; offset < n; offset += batchSize {
+		batch := buf
+		if n-offset < len(batch) {
+			batch = batch[:n-offset]
+		}
+		for i := range batch {
+			x, y, z := get(offset + i)
+			batch[i] = Coord{X: x, Y: y, Z: z, T: math.MaxFloat64}
+		}
+		if err := t.Transform(batch); err != nil {
+			return offsetErrorIndex(err, offset)
+		}
+		for i, c := range batch {
+			set(offset+i, c.X, c.Y, c.Z)
+		}
+	}
+	return nil
+}
+
+// TransformProgress transforms coordinates like Transform, in chunks of
+// chunk coordinates. progress is called after each chunk with the number of
+// transformed coordinates and the total number of coordinates. All
+// coordinates are transformed at once if progress is nil or chunk is <= 0.
+func (t *Transformer) TransformProgress(pts []Coord, chunk int, progress func(done, total int)) error {
+	if progress == nil || chunk <= 0 {
+		if err := t.Transform(pts); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(len(pts), len(pts))
+		}
+		return nil
+	}
+	for offset := 0; offset < len(pts); offset += chunk {
+		end := offset + chunk
+		if end > len(pts) {
+			end = len(pts)
+		}
+		if err := t.Transform(pts[offset:end]); err != nil {
+			return offsetErrorIndex(err, offset)
+		}
+		progress(end, len(pts))
+	}
+	return nil
+}
+
+// offsetErrorIndex adds offset to the index of TransformErrors and
+// InvalidCoordinateErrors, for errors of a sub-slice that starts at offset.
+func offsetErrorIndex(err error, offset int) error {
+	switch e := err.(type) {
+	case *TransformError:
+		if e.Index >= 0 {
+			e.Index += offset
+		}
+	case *InvalidCoordinateError:
+		e.Index += offset
+	}
+	return err
+}
+
+// CoordPair is an input coordinate and its transformed output coordinate.
+type CoordPair struct {
+	In, Out Coord
+}
+
+// TransformPairs transforms a copy of pts from src to dst projection and
+// returns each input coordinate together with its transformed coordinate.
+// pts is not modified.
+func (t *Transformer) TransformPairs(pts []Coord) ([]CoordPair, error) {
+	scratch := getScratch(len(pts))
+	defer putScratch(scratch)
+	out := *scratch
+	copy(out, pts)
+	if err := t.Transform(out); err != nil {
+		return nil, err
+	}
+	pairs := make([]CoordPair, len(pts))
+	for i := range pts {
+		pairs[i] = CoordPair{In: pts[i], Out: out[i]}
+	}
+	return pairs, nil
+}
+
+// Point2D is a two-dimensional coordinate, for use with Transform2D.
+type Point2D struct {
+	X, Y float64
+}
+
+// Transform2D transforms two-dimensional coordinates from src to dst
+// projection. Transforms coordinates in-place.
+func (t *Transformer) Transform2D(pts []Point2D) error {
+	if len(pts) == 0 {
+		return nil
+	}
+	scratch := getScratch(len(pts))
+	defer putScratch(scratch)
+	coords := *scratch
+	for i, pt := range pts {
+		coords[i] = XY(pt.X, pt.Y)
+	}
+	if err := t.Transform(coords); err != nil {
+		return err
+	}
+	for i, c := range coords {
+		pts[i] = Point2D{X: c.X, Y: c.Y}
+	}
+	return nil
 }
 
 func (t *Transformer) NormalizeForVisualization() error {
+	t.desc = nil
 	if err := t.Src.NormalizeForVisualization(); err != nil {
 		return err
 	}
@@ -235,6 +1791,78 @@ func NewTransformer(initSrc, initDst string) (Transformer, error) {
 	return Transformer{Src: src, Dst: dst}, nil
 }
 
+// NewTransformerFromProj initializes a new transformer with existing src and
+// dst projections. The transformer is normalized for visualization if
+// normalize is true (see Proj.NormalizeForVisualization). src and dst are
+// not modified, normalized transformers use normalized clones.
+func NewTransformerFromProj(src, dst *Proj, normalize bool) (Transformer, error) {
+	if src == nil || dst == nil {
+		return Transformer{}, errors.New("missing/invalid projection")
+	}
+	if normalize {
+		var err error
+		if src, err = normalizedClone(src); err != nil {
+			return Transformer{}, err
+		}
+		if dst, err = normalizedClone(dst); err != nil {
+			return Transformer{}, err
+		}
+	}
+	return Transformer{Src: src, Dst: dst}, nil
+}
+
+// NewTransformerFromFiles initializes a new transformer with src and dst
+// projection from files with CRS definitions, e.g. the .prj files of two
+// shapefiles (see NewFromFile).
+func NewTransformerFromFiles(srcPrj, dstPrj string) (Transformer, error) {
+	src, err := NewFromFile(srcPrj)
+	if err != nil {
+		return Transformer{}, fmt.Errorf("source CRS: %w", err)
+	}
+	dst, err := NewFromFile(dstPrj)
+	if err != nil {
+		return Transformer{}, fmt.Errorf("destination CRS: %w", err)
+	}
+	return Transformer{Src: src, Dst: dst}, nil
+}
+
+// normalizedClone returns p if it is already normalized, or a normalized
+// clone of p.
+func normalizedClone(p *Proj) (*Proj, error) {
+	if p.normalized {
+		return p, nil
+	}
+	c, err := p.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.NormalizeForVisualization(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WebMercatorMaxLatitude is the maximum latitude (in degree) of the web
+// mercator projection (EPSG:3857). Latitudes beyond this limit are outside of
+// the square web mercator extent.
+const WebMercatorMaxLatitude = 85.0511287798066
+
+// NewWebMercatorTransformer initializes a new transformer from EPSG:4326 to
+// web mercator (EPSG:3857). The transformer is normalized for visualization,
+// so coordinates are expected in lon/lat order.
+// Set ClampLatitude to WebMercatorMaxLatitude to avoid infinite or out of
+// extent results for coordinates near the poles.
+func NewWebMercatorTransformer() (Transformer, error) {
+	t, err := NewEPSGTransformer(4326, 3857)
+	if err != nil {
+		return Transformer{}, err
+	}
+	if err := t.NormalizeForVisualization(); err != nil {
+		return Transformer{}, err
+	}
+	return t, nil
+}
+
 // NewEPSGTransformer initializes a new transformer with src and dst projection by the numeric EPSG code.
 func NewEPSGTransformer(srcEPSG, dstEPSG int) (Transformer, error) {
 	src, err := NewEPSG(srcEPSG)
@@ -1,10 +1,17 @@
 package proj
 
 import (
+	"errors"
+	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 // Test transformation of a single point with different axis orders.
@@ -79,10 +86,7 @@ func checkTransform(t *testing.T, src, expected Coord, projA, projB string, norm
 		t.Fatal(err)
 	}
 
-	if math.Abs(pts[0].X-expected.X) > 0.01 {
-		t.Error(pts)
-	}
-	if math.Abs(pts[0].Y-expected.Y) > 0.01 {
+	if !pts[0].ApproxEqual(expected, 0.01) {
 		t.Error(pts)
 	}
 
@@ -90,12 +94,169 @@ func checkTransform(t *testing.T, src, expected Coord, projA, projB string, norm
 		t.Fatal(err)
 	}
 
-	if math.Abs(pts[0].X-src.X) > 0.0001 {
+	if !pts[0].ApproxEqual(src, 0.0001) {
 		t.Error(pts)
 	}
-	if math.Abs(pts[0].Y-src.Y) > 0.0001 {
-		t.Error(pts)
+}
+
+func TestCoordLayout(t *testing.T) {
+	var c Coord
+	size, offsets := pjCoordLayout()
+	if unsafe.Sizeof(c) != size {
+		t.Errorf("size of Coord %d != size of PJ_COORD %d", unsafe.Sizeof(c), size)
+	}
+	expected := [4]uintptr{unsafe.Offsetof(c.X), unsafe.Offsetof(c.Y), unsafe.Offsetof(c.Z), unsafe.Offsetof(c.T)}
+	if offsets != expected {
+		t.Errorf("offsets of Coord %v != offsets of PJ_COORD %v", expected, offsets)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	if CoordByteSize != 32 {
+		t.Error("unexpected CoordByteSize", CoordByteSize)
+	}
+	for dims, size := range []int{0, 8, 16, 24, 32, 0} {
+		if s := DimsByteSize(dims); s != size {
+			t.Errorf("%d != %d for %d dims", s, size, dims)
+		}
+	}
+	if DimsByteSize(4) != CoordByteSize {
+		t.Error("DimsByteSize(4) != CoordByteSize")
+	}
+}
+
+func TestCoordDistance(t *testing.T) {
+	a := Coord{1, 2, 3, 4}
+	b := Coord{4, 6, 15, 0}
+	if d := a.Distance(b); d != 5 {
+		t.Error("unexpected distance", d)
+	}
+	if d := a.Distance3D(b); d != 13 {
+		t.Error("unexpected 3D distance", d)
+	}
+	if d := a.Distance(a); d != 0 {
+		t.Error("unexpected distance", d)
+	}
+}
+
+func TestCoordApproxEqual(t *testing.T) {
+	var tests = []struct {
+		a, b  Coord
+		tol   float64
+		equal bool
+	}{
+		{XY(1, 2), XY(1, 2), 0, true},
+		{XY(1, 2), XY(1.005, 1.995), 0.01, true},
+		{XY(1, 2), XY(1.02, 2), 0.01, false},
+		{XY(1, 2), XY(1, 2.02), 0.01, false},
+		{Coord{X: 1, Y: 2, Z: 3, T: 4}, Coord{X: 1, Y: 2, Z: 3.5, T: 4}, 0.01, false},
+		{Coord{X: 1, Y: 2, Z: 3, T: 4}, Coord{X: 1, Y: 2, Z: 3, T: 5}, 0.01, false},
+		{XY(1, 2), Coord{X: 1, Y: 2}, 0.01, false},
+		{XY(math.NaN(), 2), XY(math.NaN(), 2), 0.01, false},
+	}
+	for _, tt := range tests {
+		if eq := tt.a.ApproxEqual(tt.b, tt.tol); eq != tt.equal {
+			t.Errorf("%v.ApproxEqual(%v, %v) = %v, expected %v", tt.a, tt.b, tt.tol, eq, tt.equal)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	p, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utm, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := p.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Normalized() {
+		t.Error("clone is normalized")
+	}
+
+	if err := p.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Normalized() {
+		t.Error("projection is not normalized")
+	}
+	if clone.Normalized() {
+		t.Error("normalization changed clone")
+	}
+
+	normClone, err := p.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !normClone.Normalized() {
+		t.Error("clone is not normalized")
+	}
+
+	// lon/lat for normalized projections, lat/lon for clone
+	for _, tt := range []struct {
+		p   *Proj
+		src Coord
+	}{
+		{p, XY(8.15, 53.2)},
+		{normClone, XY(8.15, 53.2)},
+		{clone, XY(53.2, 8.15)},
+	} {
+		pts := []Coord{tt.src}
+		if err := tt.p.Transform(utm, pts); err != nil {
+			t.Fatal(err)
+		}
+		if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(tt.p, pts)
+		}
+	}
+
+	p.Free()
+	pts := []Coord{XY(8.15, 53.2)}
+	if err := normClone.Transform(utm, pts); err != nil {
+		t.Fatal("clone not independent of freed source", err)
+	}
+}
+
+func TestCloneStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping stress test in short mode")
+	}
+	utm, err := New("+proj=utm +zone=32 +ellps=GRS80 +units=m +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New("+proj=longlat +ellps=GRS80 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5000; i++ {
+		// Drop the previous projection, only the clone is referenced.
+		p, err = p.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%3 == 0 {
+			if _, err := p.Clone(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if i%100 == 0 {
+			runtime.GC()
+			pts := []Coord{XY(8.15, 53.2)}
+			if err := p.Transform(utm, pts); err != nil {
+				t.Fatal(err)
+			}
+			if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+				t.Fatal(pts)
+			}
+		}
 	}
+	runtime.GC()
 }
 
 func TestTransformError(t *testing.T) {
@@ -134,11 +295,193 @@ func TestTransformError(t *testing.T) {
 	pts = []Coord{
 		XY(-81.15, 90.1),
 	}
-	if err := p1.Transform(p2, pts); err == nil || !strings.Contains(err.Error(), "Invalid coordinate") {
+	if err := p1.Transform(p2, pts); !errors.Is(err, ErrInvalidCoordinate) {
 		t.Error("no/unexpected err from transformation:", err)
 	}
 }
 
+func TestTransformInvalidCoordinate(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{ValidateGeographic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		pts       []Coord
+		index     int
+		component string
+		value     float64
+	}{
+		// lat/lon order
+		{[]Coord{XY(53.2, 8.15), XY(91.5, 8.15)}, 1, "latitude", 91.5},
+		{[]Coord{XY(-90.1, 8.15)}, 0, "latitude", -90.1},
+		{[]Coord{XY(53.2, 8.15), XY(53.2, 8.15), XY(53.2, -181)}, 2, "longitude", -181},
+		{[]Coord{XY(53.2, 360.5)}, 0, "longitude", 360.5},
+	}
+	for _, tt := range tests {
+		err := transf.Transform(tt.pts)
+		if err == nil {
+			t.Errorf("no error for %v", tt.pts)
+			continue
+		}
+		invalid, ok := err.(*InvalidCoordinateError)
+		if !ok {
+			t.Errorf("unexpected error type %T: %s", err, err)
+			continue
+		}
+		if invalid.Index != tt.index || invalid.Component != tt.component || invalid.Value != tt.value {
+			t.Errorf("unexpected error %#v for %v", invalid, tt.pts)
+		}
+	}
+
+	// custom longitude range
+	transf, err = NewTransformerWithOptions("epsg:4326", "epsg:3857", TransformOptions{ValidateGeographic: true, MinLongitude: -190, MaxLongitude: 190})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.Transform([]Coord{XY(53.2, -185)}); err != nil {
+		t.Error(err)
+	}
+	err = transf.Transform([]Coord{XY(53.2, 200)})
+	if invalid, ok := err.(*InvalidCoordinateError); !ok || invalid.Component != "longitude" || invalid.Max != 190 {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	// PROJ checks the coordinates without ValidateGeographic
+	transf, err = NewEPSGTransformer(4326, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transf.Transform([]Coord{XY(53.2, -185)}); err != nil {
+		t.Error(err)
+	}
+	if _, ok := transf.Transform([]Coord{XY(91.5, 8.15)}).(*InvalidCoordinateError); ok {
+		t.Error("coordinates validated without ValidateGeographic")
+	}
+}
+
+func TestWrapLongitudes(t *testing.T) {
+	p, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+
+	// lat/lon order
+	pts := []Coord{XY(53.2, 185), XY(53.2, -190), XY(53.2, 180), XY(53.2, -180), XY(53.2, 8.15), XY(53.2, 725)}
+	p.WrapLongitudes(pts)
+	for i, lon := range []float64{-175, 170, 180, -180, 8.15, 5} {
+		if !pts[i].ApproxEqual(XY(53.2, lon), 1e-9) {
+			t.Errorf("unexpected wrapped coordinate %v, expected longitude %v", pts[i], lon)
+		}
+	}
+
+	if err := p.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	// lon/lat order
+	pts = []Coord{XY(185, 53.2)}
+	p.WrapLongitudes(pts)
+	if !pts[0].ApproxEqual(XY(-175, 53.2), 1e-9) {
+		t.Error(pts)
+	}
+
+	utm, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer utm.Free()
+	pts = []Coord{XY(443220.719, 5894856.508)}
+	utm.WrapLongitudes(pts)
+	if pts[0] != XY(443220.719, 5894856.508) {
+		t.Error("projected coordinates modified", pts)
+	}
+}
+
+func TestTransformFailedIndex(t *testing.T) {
+	defer func(size int) { TransformChunkSize = size }(TransformChunkSize)
+	TransformChunkSize = 2
+
+	p1, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p1.Free()
+	p2, err := New("epsg:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Free()
+
+	// Transformation from UTM to web mercator fails for coordinates
+	// beyond the poles.
+	pts := []Coord{
+		XY(443220.719, 5894856.508),
+		XY(443220.719, 5894856.508),
+		XY(443220.719, 5894856.508),
+		XY(443220.719, 1e10),
+	}
+	err = p1.Transform(p2, pts)
+	transfErr, ok := err.(*TransformError)
+	if !ok {
+		t.Fatalf("unexpected error %T %v", err, err)
+	}
+	if transfErr.Index != 3 {
+		t.Error("unexpected index", transfErr.Index)
+	}
+	if !strings.Contains(err.Error(), "coordinate 3 failed") {
+		t.Error("unexpected error message", err)
+	}
+	if !errors.Is(err, ErrProj) || !errors.Is(err, ErrInvalidCoordinate) || errors.Is(err, ErrNetwork) {
+		t.Error("unexpected error category", err)
+	}
+
+	for _, errno := range []int{errnoInvalidCoord, errnoOutsideDomain} {
+		if err := (&projError{errno: errno}); !errors.Is(err, ErrInvalidCoordinate) {
+			t.Error("errno not matched by ErrInvalidCoordinate", errno)
+		}
+	}
+	if err := (&projError{errno: errnoNetwork}); errors.Is(err, ErrInvalidCoordinate) {
+		t.Error("network error matched by ErrInvalidCoordinate")
+	}
+
+	if idx := failedIndex([]Coord{XY(1, 2)}, 10); idx != -1 {
+		t.Error("unexpected index", idx)
+	}
+	if idx := failedIndex([]Coord{XY(1, 2), XY(math.Inf(1), math.Inf(1))}, 10); idx != 11 {
+		t.Error("unexpected index", idx)
+	}
+}
+
+func TestTransformChunked(t *testing.T) {
+	defer func(size int) { TransformChunkSize = size }(TransformChunkSize)
+	TransformChunkSize = 2
+
+	p1, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p1.Free()
+	p2, err := New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Free()
+
+	pts := make([]Coord, 5)
+	for i := range pts {
+		pts[i] = XY(53.2, 8.15)
+	}
+	if err := p1.Transform(p2, pts); err != nil {
+		t.Fatal(err)
+	}
+	for i := range pts {
+		if !pts[i].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(i, pts[i])
+		}
+	}
+}
+
 func TestNewTransformer(t *testing.T) {
 	pts := []Coord{
 		XY(8.15, 9.12),
@@ -149,6 +492,9 @@ func TestNewTransformer(t *testing.T) {
 	if err == nil || !strings.Contains(err.Error(), "Invalid PROJ string") {
 		t.Fatal(err)
 	}
+	if !errors.Is(err, ErrProj) {
+		t.Error("unexpected error category", err)
+	}
 
 	os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "YES")
 	defer os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "NO")
@@ -169,100 +515,1051 @@ func TestNewTransformer(t *testing.T) {
 	}
 }
 
-func TestLatLong(t *testing.T) {
-	p, err := New("epsg:4326")
+func TestTransformerOnTransform(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !p.IsLatLong() {
-		t.Error("epsg:4326 is not LatLong")
+	calls := 0
+	transf.OnTransform = func(n int, d time.Duration) {
+		calls++
+		if n != 3 {
+			t.Error("unexpected number of coordinates", n)
+		}
+		if d < 0 {
+			t.Error("unexpected duration", d)
+		}
 	}
-
-	p, err = New("epsg:25832")
-	if err != nil {
+	if err := transf.Transform([]Coord{XY(53.2, 8.15), XY(53.2, 8.15), XY(53.2, 8.15)}); err != nil {
 		t.Fatal(err)
 	}
-	if p.IsLatLong() {
-		t.Error("epsg:25832 is LatLong")
+	if err := transf.Transform([]Coord{XY(91, 8.15), XY(53.2, 8.15), XY(53.2, 8.15)}); err == nil {
+		t.Error("no err for invalid coordinate")
+	}
+	if calls != 1 {
+		t.Error("unexpected number of OnTransform calls", calls)
 	}
 }
 
-func TestNewEPSG(t *testing.T) {
-	p, err := NewEPSG(4326)
+func TestTransformRadians(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if p == nil {
-		t.Fatal("projection is nil")
+	pts := []Coord{XY(53.2*math.Pi/180, 8.15*math.Pi/180)}
+	if err := transf.TransformRadians(pts); err != nil {
+		t.Fatal(err)
 	}
-
-	p, err = NewEPSG(999999)
-	if err == nil {
-		t.Fatal("no error for unknown projection")
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
 	}
-}
 
-func TestNew(t *testing.T) {
-	os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "YES")
-	defer os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "NO")
-	p, err := New("+init=epsg:4326")
+	transf, err = NewEPSGTransformer(25832, 4326)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if p == nil {
-		t.Fatal("projection is nil")
+	if err := transf.TransformRadians(pts); err != nil {
+		t.Fatal(err)
 	}
-
-	p, err = New("+init=epsg:999999")
-	if err == nil {
-		t.Fatal("no error for unknown projection")
+	if !pts[0].ApproxEqual(XY(53.2*math.Pi/180, 8.15*math.Pi/180), 1e-8) {
+		t.Error(pts)
 	}
 
-	p, err = New("")
-	if err == nil {
-		t.Fatal("no error for empty projection")
+	// input is not modified on errors
+	transf, err = NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(53.2*math.Pi/180, 8.15*math.Pi/180), XY(95*math.Pi/180, 8.15*math.Pi/180)}
+	orig := append([]Coord(nil), pts...)
+	if err := transf.TransformRadians(pts); err == nil {
+		t.Error("no error for invalid latitude")
+	}
+	for i := range pts {
+		if pts[i] != orig[i] {
+			t.Error("input modified", i, pts[i], orig[i])
+		}
 	}
+}
 
-	p, err = New(" +proj=utm +zone=32 +ellps=GRS80 +towgs84=0,0,0,0,0,0,0 +units=m +no_defs ")
+func TestTransformAny(t *testing.T) {
+	defer func(size int) { TransformChunkSize = size }(TransformChunkSize)
+	TransformChunkSize = 2
+
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{ValidateGeographic: true})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if p == nil {
-		t.Fatal("projection is nil")
+	type point struct {
+		lat, lon, h float64
+	}
+	points := []point{{53.2, 8.15, 0}, {53.2, 8.15, 10}, {53.2, 8.15, 20}}
+	get := func(i int) (x, y, z float64) {
+		return points[i].lat, points[i].lon, points[i].h
+	}
+	set := func(i int, x, y, z float64) {
+		points[i] = point{x, y, z}
+	}
+	if err := transf.TransformAny(len(points), get, set); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range points {
+		if !XY(p.lat, p.lon).ApproxEqual(XY(443220.719, 5894856.508), 0.001) || p.h != float64(i*10) {
+			t.Error(i, p)
+		}
 	}
 
-	p, err = New(" +proj=utm +zone=99 +ellps=GRS80 +towgs84=0,0,0,0,0,0,0 +units=m +no_defs ")
-	if err == nil {
-		t.Fatal("no error for invalid projection")
+	points = []point{{53.2, 8.15, 0}, {53.2, 8.15, 0}, {91, 8.15, 0}}
+	err = transf.TransformAny(len(points), get, set)
+	invalid, ok := err.(*InvalidCoordinateError)
+	if !ok || invalid.Index != 2 {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	if err := transf.TransformAny(0, get, set); err != nil {
+		t.Error(err)
 	}
 }
 
-func TestDescription(t *testing.T) {
-	var tests = []struct {
-		epsg        int
-		description string
-	}{
-		{4326, "WGS 84"},
-		{31467, "DHDN / 3-degree Gauss-Kruger zone 3"},
-		{2222, "NAD83 / Arizona East (ft)"},
-		{2228, "NAD83 / California zone 4 (ftUS)"},
-		{2136, "Accra / Ghana National Grid"},
+func TestTransformProgress(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{ValidateGeographic: true})
+	if err != nil {
+		t.Fatal(err)
 	}
-	for _, tt := range tests {
-		p, err := NewEPSG(tt.epsg)
-		if err != nil {
-			t.Error(err)
-			return
+	pts := make([]Coord, 10)
+	for i := range pts {
+		pts[i] = XY(53.2, 8.15)
+	}
+	var done []int
+	progress := func(n, total int) {
+		if total != len(pts) {
+			t.Error("unexpected total", total)
 		}
-		if d := p.Description(); d != tt.description {
-			t.Error(d)
+		done = append(done, n)
+	}
+	if err := transf.TransformProgress(pts, 4, progress); err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 3 || done[0] != 4 || done[1] != 8 || done[2] != 10 {
+		t.Error("unexpected progress", done)
+	}
+	for _, pt := range pts {
+		if !pt.ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+			t.Fatal(pts)
 		}
 	}
+
+	pts = []Coord{XY(53.2, 8.15), XY(53.2, 8.15), XY(53.2, 8.15), XY(91, 8.15)}
+	err = transf.TransformProgress(pts, 2, func(n, total int) {})
+	invalid, ok := err.(*InvalidCoordinateError)
+	if !ok || invalid.Index != 3 {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	pts = []Coord{XY(53.2, 8.15)}
+	if err := transf.TransformProgress(pts, 2, nil); err != nil {
+		t.Fatal(err)
+	}
 }
 
-func TestUnitName(t *testing.T) {
-	var tests = []struct {
-		epsg int
+func TestCompareTransforms(t *testing.T) {
+	a, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewTransformer("epsg:4326", "+proj=utm +zone=32 +ellps=GRS80 +x_0=500001 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15), XY(53.3, 8.2)}
+	maxDelta, meanDelta, err := CompareTransforms(a, a, pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxDelta != 0 || meanDelta != 0 {
+		t.Error("unexpected delta for same transformer", maxDelta, meanDelta)
+	}
+
+	// false easting differs by 1m
+	maxDelta, meanDelta, err = CompareTransforms(a, b, pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxEqual(maxDelta, 1, 1e-6) || !approxEqual(meanDelta, 1, 1e-6) {
+		t.Error("unexpected delta", maxDelta, meanDelta)
+	}
+	if pts[0] != XY(53.2, 8.15) {
+		t.Error("input modified", pts)
+	}
+}
+
+func TestTransformerFunc(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := transf.Func()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		c, err := f(XY(53.2, 8.15))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+			t.Error(c)
+		}
+	}
+
+	if _, err := (&Transformer{}).Func(); err == nil {
+		t.Error("no error for missing projections")
+	}
+}
+
+func TestTransformMaxDelta(t *testing.T) {
+	transf, err := NewTransformer("+proj=longlat +datum=WGS84 +type=crs", "+proj=longlat +datum=WGS84 +pm=1 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(8.15, 53.2), XY(8.15, 53.2)}
+	delta, err := transf.TransformMaxDelta(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// prime meridian shifted by 1 degree
+	if !approxEqual(delta, 1, 1e-9) {
+		t.Error("unexpected delta", delta)
+	}
+	if !pts[0].ApproxEqual(XY(7.15, 53.2), 1e-9) {
+		t.Error(pts)
+	}
+
+	delta, err = transf.TransformMaxDelta(nil)
+	if err != nil || delta != 0 {
+		t.Error("unexpected delta/error for no coordinates", delta, err)
+	}
+}
+
+func TestTransformWithAccuracy(t *testing.T) {
+	transf, err := NewTransformerWithOptions("epsg:4326", "epsg:25832", TransformOptions{RecordLastUsedOperation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	transf.OnTransform = func(n int, d time.Duration) { calls++ }
+	pts := []Coord{XY(53.2, 8.15), XY(53.2, 8.15)}
+	accuracies, err := transf.TransformWithAccuracy(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// same behavior as Transform
+	if calls != 1 {
+		t.Error("OnTransform not called")
+	}
+	if transf.lastOp == nil {
+		t.Error("last used operation not set")
+	}
+	if len(accuracies) != len(pts) {
+		t.Fatal("unexpected number of accuracies", accuracies)
+	}
+	desc, err := transf.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range pts {
+		if !pts[i].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+			t.Error(pts[i])
+		}
+		if accuracies[i] != desc.Accuracy {
+			t.Error("unexpected accuracy", accuracies[i], desc.Accuracy)
+		}
+	}
+
+	// fixed operation
+	transf, err = NewTransformerWithOptions("epsg:31467", "epsg:4326", TransformOptions{PivotCRS: "EPSG:4258"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(5896773.991, 3443269.238)}
+	accuracies, err = transf.TransformWithAccuracy(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accuracies) != 1 || accuracies[0] == 0 {
+		t.Error("unexpected accuracies", accuracies)
+	}
+
+	transf, err = NewEPSGTransformer(25832, 4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(443220.719, 5894856.508), XY(443220.719, 1e10)}
+	_, err = transf.TransformWithAccuracy(pts)
+	if transfErr, ok := err.(*TransformError); !ok || transfErr.Index != 1 {
+		t.Error("unexpected error", err)
+	}
+}
+
+func TestTransformChecked(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15), XY(53.2, 8.15)}
+	if err := transf.TransformChecked(pts, 1e-9); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[1].ApproxEqual(XY(443220.719, 5894856.508), 0.001) {
+		t.Error(pts)
+	}
+
+	// clamped latitudes do not round trip
+	transf, err = NewTransformer("+proj=longlat +datum=WGS84 +type=crs", "epsg:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transf.ClampLatitude = WebMercatorMaxLatitude
+	pts = []Coord{XY(8.15, 53.2), XY(8.15, 89)}
+	err = transf.TransformChecked(pts, 1e-9)
+	residualErr, ok := err.(*ResidualError)
+	if !ok {
+		t.Fatalf("unexpected error %T %v", err, err)
+	}
+	if residualErr.Index != 1 || residualErr.Residual < 3 {
+		t.Error("unexpected error", residualErr)
+	}
+}
+
+func TestTransformPairs(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(53.2, 8.15), XY(53.2, 8.15)}
+	pairs, err := transf.TransformPairs(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 {
+		t.Fatal(pairs)
+	}
+	for i, p := range pairs {
+		if p.In != XY(53.2, 8.15) || pts[i] != XY(53.2, 8.15) {
+			t.Error("input modified", p.In, pts[i])
+		}
+		if !p.Out.ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(p.Out)
+		}
+	}
+
+	if _, err := transf.TransformPairs([]Coord{XY(91, 8.15)}); err == nil {
+		t.Error("no err for invalid coordinate")
+	}
+}
+
+func TestTransform2D(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Point2D{{53.2, 8.15}, {53.2, 8.15}}
+	if err := transf.Transform2D(pts); err != nil {
+		t.Fatal(err)
+	}
+	for _, pt := range pts {
+		if !XY(pt.X, pt.Y).ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(pts)
+		}
+	}
+
+	if err := transf.Transform2D(nil); err != nil {
+		t.Error("err from transformation with no coordinates")
+	}
+	if err := transf.Transform2D([]Point2D{{91, 8.15}}); err == nil {
+		t.Error("no err for invalid coordinate")
+	}
+}
+
+func TestTransformChan(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan Coord)
+	out := make(chan Coord, 100)
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- XY(53.2, 8.15)
+		}
+		close(in)
+	}()
+	if err := transf.TransformChan(in, out, 3); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for c := range out {
+		n++
+		if !c.ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(c)
+		}
+	}
+	if n != 10 {
+		t.Errorf("expected 10 coordinates, got %d", n)
+	}
+
+	// full batches from a slow producer
+	var batches []int
+	transf.OnTransform = func(n int, d time.Duration) { batches = append(batches, n) }
+	in = make(chan Coord)
+	out = make(chan Coord, 100)
+	go func() {
+		for i := 0; i < 7; i++ {
+			time.Sleep(time.Millisecond)
+			in <- XY(53.2, 8.15)
+		}
+		close(in)
+	}()
+	if err := transf.TransformChan(in, out, 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 || batches[0] != 3 || batches[1] != 3 || batches[2] != 1 {
+		t.Error("unexpected batches", batches)
+	}
+	transf.OnTransform = nil
+
+	// producer is not blocked after an error
+	in = make(chan Coord)
+	out = make(chan Coord, 100)
+	sent := make(chan int)
+	go func() {
+		n := 0
+		for i := 0; i < 10; i++ {
+			time.Sleep(time.Millisecond)
+			if i == 1 {
+				in <- XY(91, 8.15) // fails
+			} else {
+				in <- XY(53.2, 8.15)
+			}
+			n++
+		}
+		close(in)
+		sent <- n
+	}()
+	if err := transf.TransformChan(in, out, 2); err == nil {
+		t.Error("no err for invalid coordinate")
+	}
+	if n := <-sent; n != 10 {
+		t.Error("producer blocked", n)
+	}
+	if _, ok := <-out; ok {
+		t.Error("out not closed after error")
+	}
+}
+
+func TestNewWebMercatorTransformer(t *testing.T) {
+	transf, err := NewWebMercatorTransformer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(8.15, 53.2)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(907253.85, 7020078.53), 0.01) {
+		t.Error(pts)
+	}
+
+	transf.ClampLatitude = WebMercatorMaxLatitude
+	pts = []Coord{XY(-180, 90), XY(180, -89.5), XY(8.15, 53.2)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(-20037508.34, 20037508.34), 0.01) {
+		t.Error(pts[0])
+	}
+	if !pts[1].ApproxEqual(XY(20037508.34, -20037508.34), 0.01) {
+		t.Error(pts[1])
+	}
+	if !pts[2].ApproxEqual(XY(907253.85, 7020078.53), 0.01) {
+		t.Error(pts[2])
+	}
+}
+
+func TestNewTransformerFromProj(t *testing.T) {
+	src, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := NewEPSG(3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transf, err := NewTransformerFromProj(src, dst, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(8.15, 53.2)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(907253.85, 7020078.53), 0.01) {
+		t.Error(pts)
+	}
+	if src.Normalized() || dst.Normalized() {
+		t.Error("src/dst normalized in-place")
+	}
+
+	transf, err = NewTransformerFromProj(src, dst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts = []Coord{XY(53.2, 8.15)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(907253.85, 7020078.53), 0.01) {
+		t.Error(pts)
+	}
+
+	if _, err := NewTransformerFromProj(nil, dst, false); err == nil {
+		t.Error("no error for missing projection")
+	}
+}
+
+func TestSwapXY(t *testing.T) {
+	pts := []Coord{{1, 2, 3, 4}, XY(53.2, 8.15)}
+	SwapXY(pts)
+	if pts[0] != (Coord{2, 1, 3, 4}) || pts[1] != XY(8.15, 53.2) {
+		t.Error(pts)
+	}
+	SwapXY(nil)
+}
+
+func TestAxisOrderMatches(t *testing.T) {
+	for _, tc := range []struct {
+		epsg      int
+		normalize bool
+		latLon    bool
+	}{
+		{4326, false, true},
+		{4326, true, false},
+		{3857, false, false},
+		{25832, false, false},
+		{31467, false, true},
+	} {
+		p, err := NewEPSG(tc.epsg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.normalize {
+			if err := p.NormalizeForVisualization(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		lonLat, err := p.AxisOrderMatches(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		latLon, err := p.AxisOrderMatches(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lonLat == tc.latLon || latLon != tc.latLon {
+			t.Error("unexpected axis order for", tc.epsg, tc.normalize, lonLat, latLon)
+		}
+	}
+}
+
+func TestLikelyWrongAxisOrder(t *testing.T) {
+	latLon, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lonLat, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lonLat.NormalizeForVisualization(); err != nil {
+		t.Fatal(err)
+	}
+	utm, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		p        *Proj
+		pts      []Coord
+		expected bool
+	}{
+		{latLon, []Coord{XY(53.2, 8.15), XY(-33.9, 151.2)}, false},
+		{latLon, []Coord{XY(53.2, 8.15), XY(151.2, -33.9)}, true},
+		{latLon, []Coord{XY(8.15, 53.2)}, false}, // not detectable
+		{lonLat, []Coord{XY(151.2, -33.9)}, false},
+		{lonLat, []Coord{XY(-33.9, 151.2)}, true},
+		{utm, []Coord{XY(443220.719, 5894856.508)}, false},
+		{latLon, nil, false},
+	} {
+		if got := tt.p.LikelyWrongAxisOrder(tt.pts); got != tt.expected {
+			t.Errorf("%v for %v with %s", got, tt.pts, tt.p)
+		}
+	}
+}
+
+func TestLatLong(t *testing.T) {
+	p, err := New("epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsLatLong() {
+		t.Error("epsg:4326 is not LatLong")
+	}
+
+	p, err = New("epsg:25832")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsLatLong() {
+		t.Error("epsg:25832 is LatLong")
+	}
+}
+
+func TestNewEPSG(t *testing.T) {
+	p, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil {
+		t.Fatal("projection is nil")
+	}
+
+	p, err = NewEPSG(999999)
+	if err == nil {
+		t.Fatal("no error for unknown projection")
+	}
+}
+
+func TestNew(t *testing.T) {
+	os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "YES")
+	defer os.Setenv("PROJ_USE_PROJ4_INIT_RULES", "NO")
+	p, err := New("+init=epsg:4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil {
+		t.Fatal("projection is nil")
+	}
+
+	p, err = New("+init=epsg:999999")
+	if err == nil {
+		t.Fatal("no error for unknown projection")
+	}
+
+	p, err = New("")
+	if err == nil {
+		t.Fatal("no error for empty projection")
+	}
+
+	p, err = New(" +proj=utm +zone=32 +ellps=GRS80 +towgs84=0,0,0,0,0,0,0 +units=m +no_defs ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil {
+		t.Fatal("projection is nil")
+	}
+
+	p, err = New(" +proj=utm +zone=99 +ellps=GRS80 +towgs84=0,0,0,0,0,0,0 +units=m +no_defs ")
+	if err == nil {
+		t.Fatal("no error for invalid projection")
+	}
+}
+
+func TestNewFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	utm, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wkt, err := utm.GDALSRS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wgs84, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, def := range map[string]string{
+		"utm.prj": "\ufeff" + wkt + "\n",
+		"utm.txt": "+proj=utm +zone=32 +ellps=GRS80 +units=m +type=crs\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewFromFile(path)
+		if err != nil {
+			t.Error(name, err)
+			continue
+		}
+		pts := []Coord{XY(53.2, 8.15)}
+		if err := wgs84.Transform(p, pts); err != nil {
+			t.Fatal(err)
+		}
+		if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(name, pts)
+		}
+	}
+
+	invalid := filepath.Join(dir, "invalid.prj")
+	if err := ioutil.WriteFile(invalid, []byte("EPSG:4326 is not WKT"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromFile(invalid); err == nil || !strings.Contains(err.Error(), "unrecognized CRS definition") {
+		t.Error("no/unexpected error for invalid file", err)
+	}
+	if _, err := NewFromFile(filepath.Join(dir, "missing.prj")); err == nil {
+		t.Error("no error for missing file")
+	}
+}
+
+func TestNewTransformerFromFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcPrj := filepath.Join(dir, "src.prj")
+	dstPrj := filepath.Join(dir, "dst.prj")
+	invalidPrj := filepath.Join(dir, "invalid.prj")
+	for path, def := range map[string]string{
+		srcPrj:     `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`,
+		dstPrj:     `PROJCS["ETRS_1989_UTM_Zone_32N",GEOGCS["GCS_ETRS_1989",DATUM["D_ETRS_1989",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["False_Easting",500000.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",9.0],PARAMETER["Scale_Factor",0.9996],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`,
+		invalidPrj: "invalid",
+	} {
+		if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transf, err := NewTransformerFromFiles(srcPrj, dstPrj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ESRI WKT uses lon/lat order
+	pts := []Coord{XY(8.15, 53.2)}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(pts)
+	}
+
+	if _, err := NewTransformerFromFiles(invalidPrj, dstPrj); err == nil || !strings.HasPrefix(err.Error(), "source CRS") {
+		t.Error("no/unexpected error for invalid source", err)
+	}
+	if _, err := NewTransformerFromFiles(srcPrj, invalidPrj); err == nil || !strings.HasPrefix(err.Error(), "destination CRS") {
+		t.Error("no/unexpected error for invalid destination", err)
+	}
+}
+
+func TestNewURN(t *testing.T) {
+	utm, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// URN and EPSG code use the same EPSG axis order (lat/lon).
+	urn, err := NewURN("urn:ogc:def:crs:EPSG::4326")
+	if err != nil {
+		t.Fatal(err)
+	}
+	epsg, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*Proj{urn, epsg} {
+		pts := []Coord{XY(53.2, 8.15)}
+		if err := p.Transform(utm, pts); err != nil {
+			t.Fatal(err)
+		}
+		if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+			t.Error(p, pts)
+		}
+	}
+
+	// CRS84 uses lon/lat order.
+	crs84, err := NewURN("urn:ogc:def:crs:OGC:1.3:CRS84")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{XY(8.15, 53.2)}
+	if err := crs84.Transform(utm, pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(443220.719, 5894856.508), 0.01) {
+		t.Error(crs84, pts)
+	}
+
+	for _, invalid := range []string{"epsg:4326", "urn:ogc:def:crs:", "urn:ogc:def:crs:EPSG::999999", ""} {
+		if _, err := NewURN(invalid); err == nil {
+			t.Errorf("no error for %q", invalid)
+		}
+	}
+}
+
+func TestTransformTimeDependent(t *testing.T) {
+	geocent, err := NewEPSG(4978)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// X moves by 1m per year since 2000
+	transf := Transformer{
+		Src: geocent,
+		Dst: geocent,
+		opts: TransformOptions{
+			pipeline: "+proj=helmert +x=0 +dx=1 +t_epoch=2000 +convention=position_vector",
+		},
+	}
+	pts := []Coord{{4000000, 500000, 4900000, 2010}, {4000000, 500000, 4900000, 2000}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	// T is used, but not modified
+	if !pts[0].ApproxEqual(Coord{4000010, 500000, 4900000, 2010}, 1e-6) {
+		t.Error(pts[0])
+	}
+	if !pts[1].ApproxEqual(Coord{4000000, 500000, 4900000, 2000}, 1e-6) {
+		t.Error(pts[1])
+	}
+}
+
+func TestTransformPerPointEpoch(t *testing.T) {
+	// ITRF2014 to ETRF2000 with time-dependent Helmert transformation
+	transf, err := NewEPSGTransformer(7912, 7931)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{
+		{53.2, 8.15, 50, 2000},
+		{53.2, 8.15, 50, 2020},
+		{53.2, 8.15, 50, 2000},
+	}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if pts[0] != pts[2] {
+		t.Error("different results for same epoch", pts[0], pts[2])
+	}
+	// ETRF2000 drifts away from ITRF2014 by about 2.5cm per year
+	if math.Abs(pts[0].X-pts[1].X) < 1e-7 && math.Abs(pts[0].Y-pts[1].Y) < 1e-7 {
+		t.Error("same result for different epochs", pts[0], pts[1])
+	}
+	if pts[0].T != 2000 || pts[1].T != 2020 {
+		t.Error("epoch modified", pts)
+	}
+}
+
+func TestNewWithEpoch(t *testing.T) {
+	p, err := NewEPSG(7912)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Epoch(); ok {
+		t.Error("unexpected epoch for CRS without coordinate metadata")
+	}
+	if !hasCoordinateMetadata() {
+		if _, err := NewWithEpoch("epsg:7912", 2010); err == nil {
+			t.Error("no error for unsupported PROJ version")
+		}
+		t.Skip("coordinate metadata requires PROJ 9.2")
+	}
+
+	// ITRF2014
+	p2010, err := NewWithEpoch("epsg:7912", 2010)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch, ok := p2010.Epoch(); !ok || epoch != 2010 {
+		t.Error("unexpected epoch", epoch, ok)
+	}
+	p2020, err := NewWithEpoch("epsg:7912", 2020)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ETRF2000 is fixed to the Eurasian plate, which moves by about 2.5cm
+	// per year in ITRF2014.
+	etrf2000, err := NewEPSG(7931)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts2010 := []Coord{{X: 53.2, Y: 8.15, Z: 10}}
+	if err := p2010.Transform(etrf2000, pts2010); err != nil {
+		t.Fatal(err)
+	}
+	pts2020 := []Coord{{X: 53.2, Y: 8.15, Z: 10}}
+	if err := p2020.Transform(etrf2000, pts2020); err != nil {
+		t.Fatal(err)
+	}
+	dy := (pts2020[0].X - pts2010[0].X) * 111320
+	dx := (pts2020[0].Y - pts2010[0].Y) * 111320 * math.Cos(53.2*math.Pi/180)
+	if d := math.Hypot(dx, dy); d < 0.1 || d > 0.5 {
+		t.Error("unexpected movement between epochs", d, pts2010, pts2020)
+	}
+
+	if _, err := NewWithEpoch("epsg:999999", 2020); err == nil {
+		t.Error("no error for unknown projection")
+	}
+}
+
+func TestFrameReferenceEpoch(t *testing.T) {
+	var tests = []struct {
+		epsg    int
+		epoch   float64
+		dynamic bool
+	}{
+		{7912, 2010, true}, // ITRF2014
+		{9000, 2010, true}, // ITRF2014 2D
+		{4326, 0, false},
+		{25832, 0, false},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		epoch, dynamic, err := p.FrameReferenceEpoch()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if epoch != tt.epoch || dynamic != tt.dynamic {
+			t.Errorf("unexpected epoch %v/%v for %q", epoch, dynamic, p)
+		}
+	}
+}
+
+func TestDisableNetwork(t *testing.T) {
+	os.Setenv("PROJ_NETWORK", "ON")
+	defer os.Unsetenv("PROJ_NETWORK")
+	defer atomic.StoreInt32(&networkDisabled, 0)
+
+	p, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.networkEnabled() {
+		t.Error("network not enabled with PROJ_NETWORK=ON")
+	}
+	if !NetworkEnabled() {
+		t.Error("NetworkEnabled false with PROJ_NETWORK=ON")
+	}
+
+	DisableNetwork()
+	p, err = NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.networkEnabled() {
+		t.Error("network enabled after DisableNetwork")
+	}
+	if NetworkEnabled() {
+		t.Error("NetworkEnabled true after DisableNetwork")
+	}
+}
+
+func TestGridCacheDir(t *testing.T) {
+	if dir := GridCacheDir(); dir == "" {
+		t.Error("missing grid cache dir")
+	}
+}
+
+func TestDescription(t *testing.T) {
+	var tests = []struct {
+		epsg        int
+		description string
+	}{
+		{4326, "WGS 84"},
+		{31467, "DHDN / 3-degree Gauss-Kruger zone 3"},
+		{2222, "NAD83 / Arizona East (ft)"},
+		{2228, "NAD83 / California zone 4 (ftUS)"},
+		{2136, "Accra / Ghana National Grid"},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if d := p.Description(); d != tt.description {
+			t.Error(d)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope, err := p.Scope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scope == "" {
+		t.Error("missing scope")
+	}
+
+	p, err = New("+proj=utm +zone=32 +ellps=GRS80 +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope, err = p.Scope()
+	if err != nil || scope != "" {
+		t.Error("unexpected scope/error", scope, err)
+	}
+}
+
+func TestName(t *testing.T) {
+	var tests = []struct {
+		init string
+		name string
+	}{
+		{"epsg:4326", "WGS 84"},
+		{"epsg:25832", "ETRS89 / UTM zone 32N"},
+		{"+proj=longlat +datum=WGS84 +no_defs +type=crs", "unknown"},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.init)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if name != tt.name {
+			t.Errorf("%q != %q for %s", name, tt.name, tt.init)
+		}
+	}
+}
+
+func TestUnitName(t *testing.T) {
+	var tests = []struct {
+		epsg int
 		unit string
 	}{
 		{4326, "degree"},
@@ -283,6 +1580,332 @@ func TestUnitName(t *testing.T) {
 	}
 }
 
+func TestUnitToMeters(t *testing.T) {
+	var tests = []struct {
+		epsg   int
+		factor float64
+	}{
+		{31467, 1},
+		{2222, 0.3048},
+		{2228, 1200.0 / 3937.0},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		factor, err := p.UnitToMeters()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !approxEqual(factor, tt.factor, 1e-12) {
+			t.Errorf("%v != %v for %q", factor, tt.factor, p)
+		}
+	}
+
+	p, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UnitToMeters(); err == nil {
+		t.Error("no error for angular unit")
+	}
+}
+
+func TestCoordScaleXY(t *testing.T) {
+	c := Coord{10, 20, 30, 40}.ScaleXY(0.5)
+	if c != (Coord{5, 10, 30, 40}) {
+		t.Error(c)
+	}
+}
+
+func TestAxisCount(t *testing.T) {
+	var tests = []struct {
+		epsg  int
+		count int
+	}{
+		{4326, 2},
+		{4979, 3},
+		{25832, 2},
+		{4978, 3},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		n, err := p.AxisCount()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if n != tt.count {
+			t.Errorf("%d != %d for %q", n, tt.count, p)
+		}
+	}
+}
+
+func TestTransformVertical(t *testing.T) {
+	// MSL height to MSL depth
+	transf, err := NewEPSGTransformer(5714, 5715)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := []Coord{{53.2, 8.15, 12.5, 0}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(Coord{53.2, 8.15, -12.5, 0}, 1e-9) {
+		t.Error(pts)
+	}
+
+	// NAVD88 height to NGVD29 height (ftUS) with VERTCON grid
+	transf, err = NewEPSGTransformer(5703, 5702)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing, err := transf.MissingGrids(); err != nil || len(missing) > 0 {
+		t.Skip("VERTCON grids not available", missing, err)
+	}
+	pts = []Coord{{39.0, -98.0, 500, 0}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if pts[0].X != 39.0 || pts[0].Y != -98.0 {
+		t.Error("horizontal position modified", pts)
+	}
+	const usFoot = 1200.0 / 3937.0
+	if d := math.Abs(pts[0].Z*usFoot - 500); d < 0.001 || d > 3 {
+		t.Error("unexpected height", pts)
+	}
+}
+
+func TestCoordinateSystemType(t *testing.T) {
+	var tests = []struct {
+		epsg   int
+		csType CSType
+	}{
+		{4326, CSEllipsoidal},
+		{25832, CSCartesian},
+		{4978, CSCartesian},
+		{5714, CSVertical},
+	}
+	for _, tt := range tests {
+		p, err := NewEPSG(tt.epsg)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		csType, err := p.CoordinateSystemType()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if csType != tt.csType {
+			t.Errorf("%s != %s for %q", csType, tt.csType, p)
+		}
+	}
+
+	// compound CRS
+	p, err := NewEPSG(5555)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CoordinateSystemType(); err == nil {
+		t.Error("no error for compound CRS")
+	}
+}
+
+func TestIsGeocentric(t *testing.T) {
+	var tests = []struct {
+		init       string
+		geocentric bool
+	}{
+		{"epsg:4978", true},
+		{"+proj=geocent +datum=WGS84 +type=crs", true},
+		{"epsg:4979", false},
+		{"epsg:4326", false},
+		{"epsg:25832", false},
+		{"epsg:5555", false},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.init)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		geocentric, err := p.IsGeocentric()
+		if err != nil {
+			t.Error(tt.init, err)
+		} else if geocentric != tt.geocentric {
+			t.Errorf("unexpected IsGeocentric %v for %s", geocentric, tt.init)
+		}
+		p.Free()
+	}
+}
+
+func TestBound(t *testing.T) {
+	p, err := New("+proj=utm +zone=32 +ellps=GRS80 +towgs84=0,0,0,0,0,0,0 +units=m +no_defs +type=crs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound, err := p.IsBound(); err != nil || !bound {
+		t.Error("expected bound CRS", err)
+	}
+
+	unbound, err := p.Unbound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound, err := unbound.IsBound(); err != nil || bound {
+		t.Error("expected unbound CRS", err)
+	}
+	if unbound.IsLatLong() || unbound.UnitName() != "metre" {
+		t.Error("unexpected unbound CRS", unbound)
+	}
+
+	if _, err := unbound.Unbound(); err == nil {
+		t.Error("no error for unbound CRS")
+	}
+
+	p, err = NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound, err := p.IsBound(); err != nil || bound {
+		t.Error("expected unbound CRS", err)
+	}
+}
+
+func TestBoundToWGS84(t *testing.T) {
+	p, err := NewEPSG(31467)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, err := p.BoundToWGS84()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := bound.IsBound(); err != nil || !b {
+		t.Error("expected bound CRS", err)
+	}
+	unbound, err := bound.Unbound()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := unbound.Description(); d != "DHDN / 3-degree Gauss-Kruger zone 3" {
+		t.Error("unexpected base CRS", d)
+	}
+
+	p, err = NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.BoundToWGS84(); err == nil {
+		t.Error("no error for WGS 84")
+	}
+}
+
+func TestBaseGeographic(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := p.BaseGeographic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !base.IsLatLong() {
+		t.Error("base is not LatLong", base)
+	}
+	if name, _ := base.Name(); name != "ETRS89" {
+		t.Error("unexpected base", name)
+	}
+
+	pts := []Coord{XY(443220.719, 5894856.508)}
+	if err := p.Transform(base, pts); err != nil {
+		t.Fatal(err)
+	}
+	if !pts[0].ApproxEqual(XY(53.2, 8.15), 0.0001) {
+		t.Error(pts)
+	}
+
+	wgs84, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err = wgs84.BaseGeographic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := base.Name(); name != "WGS 84" {
+		t.Error("unexpected base", name)
+	}
+
+	// geocentric
+	ecef, err := NewEPSG(4978)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err = ecef.BaseGeographic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !base.IsLatLong() {
+		t.Error("base is not LatLong", base)
+	}
+	if geocentric, err := base.IsGeocentric(); err != nil || geocentric {
+		t.Error("base is geocentric", err)
+	}
+	if name, _ := base.Name(); name != "WGS 84" {
+		t.Error("unexpected base", name)
+	}
+}
+
+func TestToGeographic(t *testing.T) {
+	var tests = []struct {
+		init string
+		pt   Coord
+	}{
+		{"epsg:25832", XY(443220.719, 5894856.508)},
+		{"epsg:31467", XY(5896773.991, 3443269.238)},
+		{"epsg:4326", XY(53.2, 8.15)},
+		// geocentric, height of 0m
+		{"epsg:4978", Coord{X: 3790210.188, Y: 542801.789, Z: 5083907.428, T: math.MaxFloat64}},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.init)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pts := []Coord{tt.pt}
+		if err := p.ToGeographic(pts); err != nil {
+			t.Fatal(err)
+		}
+		// DHDN differs by ~100m from WGS 84 / ETRS89
+		if !pts[0].ApproxEqual(XY(8.15, 53.2), 0.01) {
+			t.Error(tt.init, pts)
+		}
+	}
+}
+
+func TestGDALSRS(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wkt, err := p.GDALSRS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(wkt, `PROJCS["ETRS89 / UTM zone 32N"`) || !strings.Contains(wkt, `AUTHORITY["EPSG","25832"]`) {
+		t.Error("unexpected WKT", wkt)
+	}
+}
+
 func BenchmarkProj(b *testing.B) {
 	pts := []Coord{
 		XY(53.1, 8.15),
@@ -310,3 +1933,37 @@ func BenchmarkProj(b *testing.B) {
 	p1.Free()
 	p2.Free()
 }
+
+func TestNewLazyContext(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	if p.Description() == "" || p.IsLatLong() || p.UnitName() != "metre" {
+		t.Error("unexpected metadata", p.Description(), p.UnitName())
+	}
+	if p.ctx != nil {
+		t.Fatal("context created for metadata-only operations")
+	}
+
+	src, err := NewEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Free()
+	transf := Transformer{Src: src, Dst: p}
+	pts := []Coord{{X: 53.2, Y: 8.15}}
+	if err := transf.Transform(pts); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(pts[0].X-443220.719) > 1e-3 || math.Abs(pts[0].Y-5894856.508) > 1e-3 {
+		t.Error("unexpected result", pts[0])
+	}
+
+	// creates dedicated context
+	p.networkEnabled()
+	if p.ctx == nil {
+		t.Error("context not created")
+	}
+}
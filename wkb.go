@@ -0,0 +1,94 @@
+package proj
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	wkbPoint = 1
+
+	// EWKB (PostGIS) flags
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// TransformWKBPoint transforms a WKB point and returns the transformed point
+// with the same byte order and type. ISO WKB (e.g. 1001 for Point Z) and
+// EWKB (PostGIS, with Z/M flags and optional SRID) points are supported.
+// The SRID of EWKB points is kept. M values are not modified.
+//
+// Coordinates are transformed in the axis order of the transformer. Call
+// NormalizeForVisualization for WKB data in lon/lat or x/y order.
+func (t *Transformer) TransformWKBPoint(wkb []byte) ([]byte, error) {
+	if len(wkb) < 5 {
+		return nil, errors.New("invalid WKB point: too short")
+	}
+	var order binary.ByteOrder
+	switch wkb[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid WKB byte order %d", wkb[0])
+	}
+
+	typ := order.Uint32(wkb[1:5])
+	offset := 5
+	hasZ := typ&ewkbZ != 0
+	hasM := typ&ewkbM != 0
+	if typ&ewkbSRID != 0 {
+		offset += 4
+	}
+	isoTyp := typ &^ (ewkbZ | ewkbM | ewkbSRID)
+	switch isoTyp / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	if isoTyp%1000 != wkbPoint || isoTyp > 3000+wkbPoint {
+		return nil, fmt.Errorf("unsupported WKB geometry type %d, expected point", typ)
+	}
+
+	dims := 2
+	if hasZ {
+		dims++
+	}
+	if hasM {
+		dims++
+	}
+	if len(wkb) != offset+dims*8 {
+		return nil, fmt.Errorf("invalid WKB point: expected %d bytes, got %d", offset+dims*8, len(wkb))
+	}
+
+	read := func(i int) float64 {
+		return math.Float64frombits(order.Uint64(wkb[offset+i*8:]))
+	}
+	pt := XY(read(0), read(1))
+	if hasZ {
+		pt.Z = read(2)
+	}
+	pts := []Coord{pt}
+	if err := t.Transform(pts); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, len(wkb))
+	copy(result, wkb)
+	write := func(i int, v float64) {
+		order.PutUint64(result[offset+i*8:], math.Float64bits(v))
+	}
+	write(0, pts[0].X)
+	write(1, pts[0].Y)
+	if hasZ {
+		write(2, pts[0].Z)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package proj
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func wkbPointBytes(order binary.ByteOrder, typ uint32, srid uint32, values ...float64) []byte {
+	b := []byte{0}
+	if order == binary.LittleEndian {
+		b[0] = 1
+	}
+	b = append(b, make([]byte, 4)...)
+	order.PutUint32(b[1:], typ)
+	if typ&ewkbSRID != 0 {
+		b = append(b, make([]byte, 4)...)
+		order.PutUint32(b[5:], srid)
+	}
+	for _, v := range values {
+		buf := make([]byte, 8)
+		order.PutUint64(buf, math.Float64bits(v))
+		b = append(b, buf...)
+	}
+	return b
+}
+
+func TestTransformWKBPoint(t *testing.T) {
+	transf, err := NewEPSGTransformer(4326, 25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		order    binary.ByteOrder
+		typ      uint32
+		in, want []float64
+	}{
+		{"2D big-endian", binary.BigEndian, 1, []float64{53.2, 8.15}, []float64{443220.719, 5894856.508}},
+		{"2D little-endian", binary.LittleEndian, 1, []float64{53.2, 8.15}, []float64{443220.719, 5894856.508}},
+		{"ISO Z", binary.LittleEndian, 1001, []float64{53.2, 8.15, 10}, []float64{443220.719, 5894856.508, 10}},
+		{"ISO ZM", binary.BigEndian, 3001, []float64{53.2, 8.15, 10, 42}, []float64{443220.719, 5894856.508, 10, 42}},
+		{"EWKB M with SRID", binary.LittleEndian, 1 | ewkbM | ewkbSRID, []float64{53.2, 8.15, 42}, []float64{443220.719, 5894856.508, 42}},
+	} {
+		in := wkbPointBytes(tt.order, tt.typ, 4326, tt.in...)
+		out, err := transf.TransformWKBPoint(in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(out) != len(in) {
+			t.Errorf("%s: unexpected length %d", tt.name, len(out))
+			continue
+		}
+		if out[0] != in[0] || string(out[1:5]) != string(in[1:5]) {
+			t.Errorf("%s: byte order/type modified", tt.name)
+		}
+		offset := 5
+		if tt.typ&ewkbSRID != 0 {
+			offset += 4
+			if tt.order.Uint32(out[5:]) != 4326 {
+				t.Errorf("%s: SRID modified", tt.name)
+			}
+		}
+		for i, want := range tt.want {
+			got := math.Float64frombits(tt.order.Uint64(out[offset+i*8:]))
+			if !approxEqual(got, want, 0.001) {
+				t.Errorf("%s: %d: %v != %v", tt.name, i, got, want)
+			}
+		}
+	}
+
+	for _, invalid := range [][]byte{
+		nil,
+		{2, 0, 0, 0, 1},
+		wkbPointBytes(binary.LittleEndian, 2, 0, 1, 2, 3, 4),
+		wkbPointBytes(binary.LittleEndian, 1, 0, 53.2),
+	} {
+		if _, err := transf.TransformWKBPoint(invalid); err == nil {
+			t.Errorf("no error for %v", invalid)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package proj
+
+// #include <proj.h>
+import "C"
+
+import (
+	"strconv"
+)
+
+// WKTVersion is the version (and flavor) of WKT.
+type WKTVersion int
+
+const (
+	WKT2_2019 WKTVersion = iota
+	WKT2_2019Simplified
+	WKT2_2015
+	WKT2_2015Simplified
+	// WKT1GDAL is WKT1 as used by GDAL, see also Proj.GDALSRS.
+	WKT1GDAL
+	// WKT1ESRI is WKT1 as used by ESRI (e.g. in .prj files).
+	WKT1ESRI
+)
+
+// WKTOptions configure the WKT output of Proj.WKT.
+type WKTOptions struct {
+	// Version of WKT, WKT2_2019 by default.
+	Version WKTVersion
+	// Multiline outputs WKT on multiple, indented lines. WKT is output as a
+	// single line otherwise.
+	Multiline bool
+	// IndentationWidth is the number of spaces for each level of
+	// indentation of multiline WKT. PROJ uses 4 spaces if 0.
+	IndentationWidth int
+}
+
+func (o WKTOptions) wktType() C.PJ_WKT_TYPE {
+	switch o.Version {
+	case WKT2_2019Simplified:
+		return C.PJ_WKT2_2019_SIMPLIFIED
+	case WKT2_2015:
+		return C.PJ_WKT2_2015
+	case WKT2_2015Simplified:
+		return C.PJ_WKT2_2015_SIMPLIFIED
+	case WKT1GDAL:
+		return C.PJ_WKT1_GDAL
+	case WKT1ESRI:
+		return C.PJ_WKT1_ESRI
+	default:
+		return C.PJ_WKT2_2019
+	}
+}
+
+// options returns the options for proj_as_wkt.
+func (o WKTOptions) options() []string {
+	if !o.Multiline {
+		return []string{"MULTILINE=NO"}
+	}
+	opts := []string{"MULTILINE=YES"}
+	if o.IndentationWidth > 0 {
+		opts = append(opts, "INDENTATION_WIDTH="+strconv.Itoa(o.IndentationWidth))
+	}
+	return opts
+}
+
+// WKT returns the CRS as WKT.
+func (p *Proj) WKT(opts WKTOptions) (string, error) {
+	cOpts := newCStringList(opts.options())
+	defer cOpts.free()
+
+	s := C.proj_as_wkt(p.context(), p.p, opts.wktType(), cOpts.ptr())
+	if s == nil {
+		return "", ctxError(p.context())
+	}
+	return C.GoString(s), nil
+}
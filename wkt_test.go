@@ -0,0 +1,47 @@
+package proj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWKT(t *testing.T) {
+	p, err := NewEPSG(25832)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wkt, err := p.WKT(WKTOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(wkt, `PROJCRS["ETRS89 / UTM zone 32N"`) || strings.Contains(wkt, "\n") {
+		t.Error("unexpected single line WKT", wkt)
+	}
+
+	wkt, err = p.WKT(WKTOptions{Multiline: true, IndentationWidth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(wkt, "\n  BASEGEOGCRS[") {
+		t.Error("unexpected multiline WKT", wkt)
+	}
+
+	for _, tt := range []struct {
+		version WKTVersion
+		prefix  string
+	}{
+		{WKT2_2015, "PROJCRS["},
+		{WKT1GDAL, "PROJCS["},
+		{WKT1ESRI, "PROJCS["},
+	} {
+		wkt, err := p.WKT(WKTOptions{Version: tt.version})
+		if err != nil {
+			t.Error(tt.version, err)
+			continue
+		}
+		if !strings.HasPrefix(wkt, tt.prefix) {
+			t.Error("unexpected WKT", tt.version, wkt)
+		}
+	}
+}